@@ -0,0 +1,443 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mcubik/goverreport/report"
+	"gopkg.in/yaml.v2"
+)
+
+// Prefix of the environment variables that override configuration keys
+const envPrefix = "GOVERREPORT_"
+
+// Contents of a configuration file, which may extend another one
+type configDocument struct {
+	configuration `yaml:",inline"`
+	Extends       string `yaml:"extends,omitempty"`
+}
+
+// Origin of each top level configuration key: a file or an environment variable
+type configSources map[string]string
+
+// Loads the effective configuration: the given file or, if there's none, the
+// one found from the working directory, followed by the keys of the selected
+// profile, if any, and the environment overrides. Also returns where every
+// key came from.
+func resolveConfig(filename, profile string, lookupEnv func(string) (string, bool)) (configuration, configSources, error) {
+	if filename == "" {
+		filename = findConfig(".")
+	}
+	values := make(map[string]interface{})
+	sources := configSources{}
+	if filename != "" {
+		if err := mergeConfigFile(filename, values, sources, map[string]bool{}); err != nil {
+			return configuration{}, nil, err
+		}
+	}
+	if profile != "" {
+		if err := applyProfile(profile, values, sources); err != nil {
+			return configuration{}, nil, err
+		}
+	}
+	for _, key := range configKeys() {
+		name := envName(key)
+		text, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(text), &value); err != nil {
+			return configuration{}, nil, fmt.Errorf("Invalid value of %s: %s", name, err)
+		}
+		// The line numbers refer to the decoded value, not to anything the user wrote
+		_, problems := decodeConfig(map[string]interface{}{key: value})
+		for i, problem := range problems {
+			problems[i] = linePrefix.ReplaceAllString(problem, "")
+		}
+		if err := configError("value of "+name, problems); err != nil {
+			return configuration{}, nil, err
+		}
+		values[key] = value
+		sources[key] = "env " + name
+	}
+	conf, problems := decodeConfig(values)
+	if err := configError("configuration", problems); err != nil {
+		return configuration{}, nil, err
+	}
+	return conf, sources, nil
+}
+
+// Replaces the top level keys with the ones of a profile
+func applyProfile(name string, values map[string]interface{}, sources configSources) error {
+	profiles, _ := values["profiles"].(map[interface{}]interface{})
+	profile, ok := profiles[name].(map[interface{}]interface{})
+	if !ok && len(profiles) == 0 {
+		return fmt.Errorf("Unknown profile '%s', the configuration has no profiles", name)
+	}
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, fmt.Sprint(n))
+		}
+		sort.Strings(names)
+		return fmt.Errorf("Unknown profile '%s', must be one of: %s", name, strings.Join(names, ", "))
+	}
+	for key, value := range profile {
+		values[fmt.Sprint(key)] = value
+		sources[fmt.Sprint(key)] = fmt.Sprintf("profile %s in %s", name, sources["profiles"])
+	}
+	return nil
+}
+
+// Finds the configuration file in a directory or its parents, stopping at
+// the root of the repository. Returns an empty string if there isn't one.
+func findConfig(dir string) string {
+	for {
+		file := filepath.Join(dir, configFile)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil || filepath.Dir(abs) == abs {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		dir = filepath.Join(dir, "..")
+	}
+}
+
+// Finds the directory the source files are relative to: the one with the
+// go.mod of the module in dir or its parents or, outside a module, the one of
+// the configuration file, given or found from dir
+func findSourceDir(dir, filename string) string {
+	for parent := dir; ; parent = filepath.Join(parent, "..") {
+		if _, err := os.Stat(filepath.Join(parent, "go.mod")); err == nil {
+			return parent
+		}
+		abs, err := filepath.Abs(parent)
+		if err != nil || filepath.Dir(abs) == abs {
+			break
+		}
+	}
+	if filename == "" {
+		filename = findConfig(dir)
+	}
+	if filename != "" {
+		return filepath.Dir(filename)
+	}
+	return dir
+}
+
+// Reads the top level keys of a configuration file into values, after the
+// ones of the file it extends, if any. The path of an extended file is
+// relative to the file that extends it.
+func mergeConfigFile(filename string, values map[string]interface{}, sources configSources, seen map[string]bool) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if seen[abs] {
+		return fmt.Errorf("Circular extends in '%s'", filename)
+	}
+	seen[abs] = true
+	// #nosec G304 -- reads the configuration file chosen by the user
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var strict configDocument
+	if err := yaml.UnmarshalStrict(data, &strict); err != nil {
+		return configError(fmt.Sprintf("configuration '%s'", filename), describeYAMLError(err))
+	}
+	if err := configError(fmt.Sprintf("configuration '%s'", filename), validateConfig(strict.configuration)); err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("Invalid configuration '%s': %s", filename, err)
+	}
+	if extends, ok := doc["extends"]; ok {
+		base, ok := extends.(string)
+		if !ok || base == "" {
+			return fmt.Errorf("Invalid extends in '%s', must be a file name", filename)
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(filename), base)
+		}
+		if err := mergeConfigFile(base, values, sources, seen); err != nil {
+			return err
+		}
+		delete(doc, "extends")
+	}
+	for key, value := range doc {
+		values[key] = value
+		sources[key] = filename
+	}
+	return nil
+}
+
+// Builds the configuration from its top level keys, returning the problems
+// found decoding and validating it
+func decodeConfig(values map[string]interface{}) (configuration, []string) {
+	conf := configuration{Exclusions: []string{}}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return conf, []string{err.Error()}
+	}
+	if err := yaml.UnmarshalStrict(data, &conf); err != nil {
+		return conf, describeYAMLError(err)
+	}
+	return conf, validateConfig(conf)
+}
+
+// Reports the problems found in a configuration source as an error, or
+// returns nil if there are none
+func configError(source string, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Invalid %s:\n  %s", source, strings.Join(problems, "\n  "))
+}
+
+// Checks the values of the configuration, reporting all the problems found
+func validateConfig(conf configuration) []string {
+	var problems []string
+	check := func(ok bool, format string, a ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, a...))
+		}
+	}
+	isMetric := func(metric string) bool {
+		_, err := report.Metric(report.Summary{}, metric)
+		return err == nil
+	}
+	isPercentage := func(value float64) bool {
+		return value >= 0 && value <= 100
+	}
+	check(isPercentage(conf.Threshold), "threshold must be between 0 and 100, got %g", conf.Threshold)
+	check(conf.Metric == "" || isMetric(conf.Metric), "thresholdType must be block or stmt, got '%s'", conf.Metric)
+	check(conf.Colors.Metric == "" || isMetric(conf.Colors.Metric), "colors.metric must be block or stmt, got '%s'", conf.Colors.Metric)
+	check(isPercentage(conf.Colors.Red), "colors.red must be between 0 and 100, got %g", conf.Colors.Red)
+	check(isPercentage(conf.Colors.Yellow), "colors.yellow must be between 0 and 100, got %g", conf.Colors.Yellow)
+	check(isPercentage(conf.Below), "below must be between 0 and 100, got %g", conf.Below)
+	check(isPercentage(conf.Above), "above must be between 0 and 100, got %g", conf.Above)
+	check(conf.FilterMetric == "" || isMetric(conf.FilterMetric), "filterMetric must be block or stmt, got '%s'", conf.FilterMetric)
+	check(conf.Top >= 0, "top can't be negative, got %d", conf.Top)
+	check(conf.MinStmts >= 0, "minStmts can't be negative, got %d", conf.MinStmts)
+	if conf.Sort != "" || conf.Order != "" {
+		sortBy, order := conf.Sort, conf.Order
+		if sortBy == "" {
+			sortBy = "filename"
+		}
+		if order == "" {
+			order = "asc"
+		}
+		err := report.SortSummaries(nil, sortBy, order)
+		check(err == nil, "sort: %v", err)
+	}
+	check(conf.Format == "" || oneOf(conf.Format, outputFormats), "format must be one of %s, got '%s'", strings.Join(outputFormats, ", "), conf.Format)
+	check(oneOf(conf.Color, []string{"", "auto", "always", "never"}), "color must be auto, always or never, got '%s'", conf.Color)
+	check(oneOf(conf.Group, []string{"", "package"}), "group must be package, got '%s'", conf.Group)
+	check(oneOf(conf.VerifySources, []string{"", "warn", "fail"}), "verifySources must be warn or fail, got '%s'", conf.VerifySources)
+	err := report.ValidatePathMappings(pathMappings(conf))
+	check(err == nil, "pathMappings: %v", err)
+	if len(conf.Columns) > 0 {
+		_, err := report.ParseColumns(conf.Columns)
+		check(err == nil, "columns: %v", err)
+	}
+	for _, output := range conf.Outputs {
+		check(oneOf(output.Format, outputFormats), "outputs: format must be one of %s, got '%s'", strings.Join(outputFormats, ", "), output.Format)
+		check(output.Output != "", "outputs: missing output file for format '%s'", output.Format)
+	}
+	names := make([]string, 0, len(conf.Profiles))
+	for name := range conf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := conf.Profiles[name]
+		check(len(profile.Profiles) == 0, "profiles.%s: profiles can't be nested", name)
+		for _, problem := range validateConfig(profile) {
+			problems = append(problems, fmt.Sprintf("profiles.%s: %s", name, problem))
+		}
+	}
+	for _, rule := range conf.Policy {
+		_, err := parseCondition(rule.Condition)
+		check(err == nil, "policy: %v", err)
+		check(rule.Scope == "" || rule.Scope == "total" || rule.Scope == "files",
+			"policy: invalid scope '%s', use 'total' or 'files'", rule.Scope)
+	}
+	return problems
+}
+
+func oneOf(value string, values []string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type (\S+)$`)
+	linePrefix          = regexp.MustCompile(`^line \d+: `)
+)
+
+// Rewrites the errors of a strict yaml decoding, suggesting the closest
+// known key for the unknown ones
+func describeYAMLError(err error) []string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []string{err.Error()}
+	}
+	keys := configTypeKeys()
+	messages := make([]string, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		match := unknownFieldPattern.FindStringSubmatch(message)
+		if match == nil {
+			messages = append(messages, message)
+			continue
+		}
+		message = fmt.Sprintf("line %s: unknown key '%s'", match[1], match[2])
+		if suggestion := closestKey(match[2], keys[match[3]]); suggestion != "" {
+			message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// Known keys of the configuration and its nested sections, by type name
+func configTypeKeys() map[string][]string {
+	keys := make(map[string][]string)
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for t.Kind() == reflect.Slice || t.Kind() == reflect.Map || t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || keys[t.String()] != nil {
+			return
+		}
+		keys[t.String()] = []string{}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			keys[t.String()] = append(keys[t.String()], name)
+			collect(t.Field(i).Type)
+		}
+	}
+	collect(reflect.TypeOf(configuration{}))
+	keys["main.configDocument"] = append(keys["main.configuration"], "extends")
+	return keys
+}
+
+// Returns the known key closest to an unknown one, or an empty string if
+// none is close enough to be a typo
+func closestKey(key string, known []string) string {
+	// Allow a typo every three characters
+	best, bestDistance := "", len(key)/3+1
+	for _, candidate := range known {
+		if strings.EqualFold(key, candidate) {
+			return candidate
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// Top level keys of the configuration
+func configKeys() []string {
+	t := reflect.TypeOf(configuration{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0])
+	}
+	return keys
+}
+
+// Name of the environment variable that overrides a key, e.g.
+// GOVERREPORT_THRESHOLD_TYPE for thresholdType
+func envName(key string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	for _, r := range key {
+		if unicode.IsUpper(r) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// Prints the effective configuration, with the origin of every key
+func printConfig(conf configuration, sources configSources, w io.Writer) error {
+	data, err := yaml.Marshal(maskSecrets(conf))
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		key, _, found := strings.Cut(line, ":")
+		if !found || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") {
+			b.WriteString(line)
+			continue
+		}
+		source, ok := sources[key]
+		if !ok {
+			source = "default"
+		}
+		b.WriteString(strings.TrimSuffix(line, "\n") + "  # " + source + "\n")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Hides the upload token, including the ones of the profiles, so that the
+// output of the config command can be shared
+func maskSecrets(conf configuration) configuration {
+	if conf.Token != "" {
+		conf.Token = "***"
+	}
+	if conf.Profiles != nil {
+		profiles := make(map[string]configuration, len(conf.Profiles))
+		for name, profile := range conf.Profiles {
+			profiles[name] = maskSecrets(profile)
+		}
+		conf.Profiles = profiles
+	}
+	return conf
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcubik/goverreport/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0750))
+	require.NoError(t, os.WriteFile(name, []byte(content), 0600))
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func TestFindConfig(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repo", ".git"), 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repo", "a", "b"), 0750))
+	assert.Equal("", findConfig(filepath.Join(dir, "repo", "a", "b")))
+
+	// Files above the root of the repository are ignored
+	writeFile(t, filepath.Join(dir, configFile), "")
+	assert.Equal("", findConfig(filepath.Join(dir, "repo", "a", "b")))
+
+	writeFile(t, filepath.Join(dir, "repo", configFile), "")
+	assert.Equal(filepath.Join(dir, "repo", configFile), findConfig(filepath.Join(dir, "repo", "a", "b")))
+	writeFile(t, filepath.Join(dir, "repo", "a", configFile), "")
+	assert.Equal(filepath.Join(dir, "repo", "a", configFile), findConfig(filepath.Join(dir, "repo", "a", "b")))
+}
+
+func TestConfigExtends(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared", "base.yml"), "root: example.com/mod\nthreshold: 70\nexclusions: [vendor]\n")
+	writeFile(t, filepath.Join(dir, "svc", configFile), "extends: ../shared/base.yml\nthreshold: 85\n")
+	conf, sources, err := resolveConfig(filepath.Join(dir, "svc", configFile), "", noEnv)
+	assert.NoError(err)
+	assert.Equal(configuration{Root: "example.com/mod", Exclusions: []string{"vendor"}, Threshold: 85}, conf)
+	assert.Equal(configSources{
+		"root":       filepath.Join(dir, "shared", "base.yml"),
+		"exclusions": filepath.Join(dir, "shared", "base.yml"),
+		"threshold":  filepath.Join(dir, "svc", configFile)}, sources)
+
+	conf, _, err = resolveConfig(filepath.Join(dir, "svc", configFile), "", noEnv)
+	assert.NoError(err)
+	assert.Equal(85.0, conf.Threshold)
+
+	writeFile(t, filepath.Join(dir, "loop.yml"), "extends: loop.yml\n")
+	_, _, err = resolveConfig(filepath.Join(dir, "loop.yml"), "", noEnv)
+	assert.EqualError(err, "Circular extends in '"+filepath.Join(dir, "loop.yml")+"'")
+
+	writeFile(t, filepath.Join(dir, "bad.yml"), "extends: [a, b]\n")
+	_, _, err = resolveConfig(filepath.Join(dir, "bad.yml"), "", noEnv)
+	assert.Error(err)
+	writeFile(t, filepath.Join(dir, "missing.yml"), "extends: xxx.yml\n")
+	_, _, err = resolveConfig(filepath.Join(dir, "missing.yml"), "", noEnv)
+	assert.Error(err)
+	writeFile(t, filepath.Join(dir, "invalid.yml"), "threshold: [\n")
+	_, _, err = resolveConfig(filepath.Join(dir, "invalid.yml"), "", noEnv)
+	assert.Error(err)
+
+	_, _, err = resolveConfig(filepath.Join(dir, "xxx.yml"), "", noEnv)
+	assert.Error(err, "An explicit configuration file must exist")
+}
+
+func TestConfigEnvironment(t *testing.T) {
+	assert := assert.New(t)
+	env := map[string]string{
+		"GOVERREPORT_THRESHOLD_TYPE": "block",
+		"GOVERREPORT_EXCLUSIONS":     "[a, b]",
+		"GOVERREPORT_XXX":            "ignored"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	conf, sources, err := resolveConfig(".goverreport.yml", "", lookupEnv)
+	assert.NoError(err)
+	assert.Equal(configuration{Root: "github.com/mcubik/goverreport", Exclusions: []string{"a", "b"}, Threshold: 80, Metric: "block"}, conf)
+	assert.Equal("env GOVERREPORT_THRESHOLD_TYPE", sources["thresholdType"])
+	assert.Equal(".goverreport.yml", sources["root"])
+
+	env["GOVERREPORT_THRESHOLD"] = "[80"
+	_, _, err = resolveConfig(".goverreport.yml", "", lookupEnv)
+	assert.Error(err)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "GOVERREPORT_ROOT", envName("root"))
+	assert.Equal(t, "GOVERREPORT_WATCH_COMMAND", envName("watchCommand"))
+	assert.Contains(t, configKeys(), "thresholdType")
+}
+
+func TestPrintConfig(t *testing.T) {
+	buf := bytes.Buffer{}
+	conf := configuration{Exclusions: []string{"test"}, Threshold: 80, Policy: []policyRule{{Condition: "stmt >= 80"}}}
+	sources := configSources{"exclusions": "a.yml", "threshold": "env GOVERREPORT_THRESHOLD", "policy": "a.yml"}
+	assert.NoError(t, printConfig(conf, sources, &buf))
+	assert.Equal(t, `root: ""  # default
+exclusions:  # a.yml
+- test
+threshold: 80  # env GOVERREPORT_THRESHOLD
+policy:  # a.yml
+- condition: stmt >= 80
+`, buf.String())
+
+	buf.Reset()
+	conf = configuration{Token: "secret", Profiles: map[string]configuration{"ci": {Token: "other"}}}
+	assert.NoError(t, printConfig(conf, configSources{"token": "env GOVERREPORT_TOKEN"}, &buf))
+	assert.Contains(t, buf.String(), "token: '***'  # env GOVERREPORT_TOKEN\n")
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "other")
+	assert.Equal(t, "secret", conf.Token, "The configuration isn't modified")
+}
+
+func TestConfigUnknownKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), configFile)
+	writeFile(t, file, "root: x\nthresholdtype: stmt\nexclusion: [a]\ncolors:\n  yelow: 3\nfoo: 1\n")
+	_, _, err := resolveConfig(file, "", noEnv)
+	assert.EqualError(t, err, "Invalid configuration '"+file+"':\n"+
+		"  line 2: unknown key 'thresholdtype', did you mean 'thresholdType'?\n"+
+		"  line 3: unknown key 'exclusion', did you mean 'exclusions'?\n"+
+		"  line 5: unknown key 'yelow', did you mean 'yellow'?\n"+
+		"  line 6: unknown key 'foo'")
+}
+
+func TestConfigValidation(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), configFile)
+	writeFile(t, file, "threshold: 120\nthresholdType: line\ncolumns: [xx]\ncolors: {metric: x, red: -1, yellow: 101}\n"+
+		"policy: [{condition: stmt, scope: all}]\n")
+	_, _, err := resolveConfig(file, "", noEnv)
+	assert.EqualError(err, "Invalid configuration '"+file+"':\n"+
+		"  threshold must be between 0 and 100, got 120\n"+
+		"  thresholdType must be block or stmt, got 'line'\n"+
+		"  colors.metric must be block or stmt, got 'x'\n"+
+		"  colors.red must be between 0 and 100, got -1\n"+
+		"  colors.yellow must be between 0 and 100, got 101\n"+
+		"  columns: Invalid column 'xx', must be one of "+strings.Join(report.ColumnIDs(), ", ")+"\n"+
+		"  policy: Invalid condition 'stmt', use <metric> <operator> <value>, e.g. 'stmt >= 80'\n"+
+		"  policy: invalid scope 'all', use 'total' or 'files'")
+
+	env := map[string]string{"GOVERREPORT_COLORS": "{red: x, blue: 3}"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	_, _, err = resolveConfig(".goverreport.yml", "", lookupEnv)
+	assert.EqualError(err, "Invalid value of GOVERREPORT_COLORS:\n  unknown key 'blue'\n  cannot unmarshal !!str `x` into float64")
+	env = map[string]string{"GOVERREPORT_THRESHOLD": "101"}
+	_, _, err = resolveConfig(".goverreport.yml", "", lookupEnv)
+	assert.EqualError(err, "Invalid value of GOVERREPORT_THRESHOLD:\n  threshold must be between 0 and 100, got 101")
+}
+
+func TestClosestKey(t *testing.T) {
+	assert := assert.New(t)
+	keys := []string{"root", "exclusions", "threshold", "thresholdType"}
+	assert.Equal("thresholdType", closestKey("THRESHOLDTYPE", keys))
+	assert.Equal("threshold", closestKey("treshold", keys))
+	assert.Equal("", closestKey("foo", keys))
+	assert.Equal(3, editDistance("kitten", "sitting"))
+	assert.Equal(0, editDistance("", ""))
+}
+
+func TestConfigValidationFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), configFile)
+	writeFile(t, file, "sort: lines\nformat: xml\ncolor: red\ngroup: dir\nverifySources: x\nfilterMetric: x\nbelow: 200\ntop: -1\nminStmts: -2\ncsvHeader: false\n"+
+		"pathMappings: [{from: '(', to: x, regexp: true}]\n")
+	_, _, err := resolveConfig(file, "", noEnv)
+	assert.EqualError(t, err, "Invalid configuration '"+file+"':\n"+
+		"  below must be between 0 and 100, got 200\n"+
+		"  filterMetric must be block or stmt, got 'x'\n"+
+		"  top can't be negative, got -1\n"+
+		"  minStmts can't be negative, got -2\n"+
+		"  sort: Invalid sort column 'lines', must be one of "+strings.Join(report.SortKeys(), ", ")+"\n"+
+		"  format must be one of table, json, html, openmetrics, sonar, csv, tsv, got 'xml'\n"+
+		"  color must be auto, always or never, got 'red'\n"+
+		"  group must be package, got 'dir'\n"+
+		"  verifySources must be warn or fail, got 'x'\n"+
+		"  pathMappings: Invalid path mapping '(': error parsing regexp: missing closing ): `(`")
+
+	writeFile(t, file, "order: desc\ncsvHeader: false\n")
+	conf, _, err := resolveConfig(file, "", noEnv)
+	assert.NoError(t, err)
+	assert.False(t, *conf.CSVHeader)
+}
+
+func TestConfigProfiles(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), configFile)
+	writeFile(t, file, `threshold: 70
+format: table
+profiles:
+  pr:
+    threshold: 85
+    outputs: [{format: json, output: coverage.json}]
+  dev: {top: 3}
+`)
+	conf, sources, err := resolveConfig(file, "pr", noEnv)
+	assert.NoError(err)
+	assert.Equal(85.0, conf.Threshold)
+	assert.Equal("table", conf.Format)
+	assert.Equal([]outputConfig{{Format: "json", Output: "coverage.json"}}, conf.Outputs)
+	assert.Equal("profile pr in "+file, sources["threshold"])
+	assert.Equal(file, sources["format"])
+
+	// The environment takes precedence over the profile
+	_, sources, err = resolveConfig(file, "pr", func(name string) (string, bool) {
+		return "90", name == "GOVERREPORT_THRESHOLD"
+	})
+	assert.NoError(err)
+	assert.Equal("env GOVERREPORT_THRESHOLD", sources["threshold"])
+
+	_, _, err = resolveConfig(file, "xxx", noEnv)
+	assert.EqualError(err, "Unknown profile 'xxx', must be one of: dev, pr")
+	_, _, err = resolveConfig(".goverreport.yml", "xxx", noEnv)
+	assert.EqualError(err, "Unknown profile 'xxx', the configuration has no profiles")
+
+	writeFile(t, file, `profiles:
+  a: {profiles: {b: {}}, top: -1}
+  b: {outputs: [{format: xml}]}
+`)
+	_, _, err = resolveConfig(file, "", noEnv)
+	assert.EqualError(err, "Invalid configuration '"+file+"':\n"+
+		"  profiles.a: profiles can't be nested\n"+
+		"  profiles.a: top can't be negative, got -1\n"+
+		"  profiles.b: outputs: format must be one of table, json, html, openmetrics, sonar, csv, tsv, got 'xml'\n"+
+		"  profiles.b: outputs: missing output file for format 'xml'")
+}
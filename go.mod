@@ -3,8 +3,10 @@ module github.com/mcubik/goverreport
 go 1.21
 
 require (
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/olekukonko/tablewriter v1.1.0
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/term v0.20.0
 	golang.org/x/tools v0.21.0
 	gopkg.in/yaml.v2 v2.4.0
 )
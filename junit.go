@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// JUnit XML report
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Writes the threshold results as a JUnit XML file, one test case per threshold
+func writeJUnit(filename string, results []thresholdResult) error {
+	suite := junitSuite{Name: "goverreport", Tests: len(results), Cases: []junitCase{}}
+	for _, r := range results {
+		testCase := junitCase{
+			ClassName: "goverreport",
+			Name:      fmt.Sprintf("%s: %s %s %s %.2f", r.Rule, r.Name, r.Metric, r.Op, r.Threshold)}
+		if !r.Passed {
+			message := fmt.Sprintf("%s %s is %.2f, required %s %.2f", r.Name, r.Metric, r.Value, r.Op, r.Threshold)
+			testCase.Failure = &junitFailure{Message: message, Text: message}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	data, err := xml.MarshalIndent(junitSuites{Suites: []junitSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	return os.WriteFile(filename, data, 0600)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "junit.xml")
+	require.NoError(t, writeJUnit(filename, []thresholdResult{
+		{Rule: "threshold", Name: "Total", Metric: "block", Op: ">=", Threshold: 80, Value: 79.9}}))
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="goverreport" tests="1" failures="1">
+    <testcase classname="goverreport" name="threshold: Total block &gt;= 80.00">
+      <failure message="Total block is 79.90, required &gt;= 80.00">Total block is 79.90, required &gt;= 80.00</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(data))
+
+	assert.Error(t, writeJUnit(filepath.Join(t.TempDir(), "xxx", "junit.xml"), nil))
+}
+
+func TestRunJUnit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "junit.xml")
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		threshold:    75,
+		metric:       "block",
+		sortBy:       "filename",
+		order:        "asc",
+		junit:        filename}
+	results, err := run(configuration{}, args, new(bytes.Buffer))
+	assert.NoError(t, err)
+	assert.True(t, results.passed())
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `tests="1" failures="0"`)
+
+	args.junit = filepath.Join(t.TempDir(), "xxx", "junit.xml")
+	_, err = run(configuration{}, args, new(bytes.Buffer))
+	assert.Error(t, err)
+}
@@ -5,118 +5,617 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/mcubik/goverreport/report"
-	"gopkg.in/yaml.v2"
+	"github.com/mcubik/goverreport/tui"
+	"golang.org/x/term"
 )
 
 // Command arguments
 type arguments struct {
+	command                             string
 	coverprofile, metric, sortBy, order string
-	threshold                           float64
-	metricDefaulted                     bool
-	packages                            bool
+	format, testsDir, coveredBy         string
+	watchCommand, addr, token           string
+	junit, output, columns, color       string
+	result, verifySources, config       string
+	profile, group, tags, sourceDir     string
+	filterMetric                        string
+	threshold, below, above             float64
+	top, minStmts                       int
+	untested, filterTotal, scanSources  bool
+	belowSet, aboveSet                  bool
+	packages, watch, bar                bool
+	csvHeader, csvTotal                 bool
 }
 
 var args arguments
 
 const configFile = ".goverreport.yml"
 
-// Configuration
+// Configuration. The fields with a flag tag set the default of that command
+// line flag.
 type configuration struct {
 	Root       string   `yaml:"root"`
 	Exclusions []string `yaml:"exclusions"`
-	Threshold  float64  `yaml:"threshold,omitempty"`
-	Metric     string   `yaml:"thresholdType,omitempty"`
+	Threshold  float64  `yaml:"threshold,omitempty" flag:"threshold" schema:"minimum=0,maximum=100"`
+	Metric     string   `yaml:"thresholdType,omitempty" flag:"metric" schema:"enum=block|stmt"`
+
+	PathMappings []pathMapping `yaml:"pathMappings,omitempty"`
+	Policy       []policyRule  `yaml:"policy,omitempty"`
+	Columns      []string      `yaml:"columns,omitempty" flag:"columns"`
+	Colors       colorConfig   `yaml:"colors,omitempty"`
+	WatchCommand string        `yaml:"watchCommand,omitempty" flag:"watch-command"`
+	OpenMetrics  metricsConfig `yaml:"openmetrics,omitempty"`
+
+	Coverprofile  string  `yaml:"coverprofile,omitempty" flag:"coverprofile"`
+	Sort          string  `yaml:"sort,omitempty" flag:"sort"`
+	Order         string  `yaml:"order,omitempty" flag:"order" schema:"enum=asc|desc"`
+	Packages      bool    `yaml:"packages,omitempty" flag:"packages"`
+	Group         string  `yaml:"group,omitempty" flag:"group" schema:"enum=package"`
+	Format        string  `yaml:"format,omitempty" flag:"format" schema:"enum=table|json|html|openmetrics|sonar|csv|tsv"`
+	Bar           bool    `yaml:"bar,omitempty" flag:"bar"`
+	Color         string  `yaml:"color,omitempty" flag:"color" schema:"enum=auto|always|never"`
+	VerifySources string  `yaml:"verifySources,omitempty" flag:"verify-sources" schema:"enum=warn|fail"`
+	ScanSources   bool    `yaml:"scanSources,omitempty" flag:"scan-sources"`
+	Tags          string  `yaml:"tags,omitempty" flag:"tags"`
+	Below         float64 `yaml:"below,omitempty" flag:"below" schema:"minimum=0,maximum=100"`
+	Above         float64 `yaml:"above,omitempty" flag:"above" schema:"minimum=0,maximum=100"`
+	Untested      bool    `yaml:"untested,omitempty" flag:"untested"`
+	FilterMetric  string  `yaml:"filterMetric,omitempty" flag:"filter-metric" schema:"enum=block|stmt"`
+	FilterTotal   bool    `yaml:"filterTotal,omitempty" flag:"filter-total"`
+	Top           int     `yaml:"top,omitempty" flag:"top" schema:"minimum=0"`
+	MinStmts      int     `yaml:"minStmts,omitempty" flag:"min-stmts" schema:"minimum=0"`
+	Output        string  `yaml:"output,omitempty" flag:"output"`
+	CSVHeader     *bool   `yaml:"csvHeader,omitempty" flag:"csv-header"`
+	CSVTotal      bool    `yaml:"csvTotal,omitempty" flag:"csv-total"`
+	Tests         string  `yaml:"tests,omitempty" flag:"tests"`
+	CoveredBy     string  `yaml:"coveredBy,omitempty" flag:"covered-by"`
+	JUnit         string  `yaml:"junit,omitempty" flag:"junit"`
+	Result        string  `yaml:"result,omitempty" flag:"result"`
+	Watch         bool    `yaml:"watch,omitempty" flag:"watch"`
+	Addr          string  `yaml:"addr,omitempty" flag:"addr"`
+	Token         string  `yaml:"token,omitempty" flag:"token"`
+
+	Outputs  []outputConfig           `yaml:"outputs,omitempty"`
+	Profiles map[string]configuration `yaml:"profiles,omitempty"`
+}
+
+// Additional output of the report, written to a file
+type outputConfig struct {
+	Format string `yaml:"format" schema:"enum=table|json|html|openmetrics|sonar|csv|tsv"`
+	Output string `yaml:"output"`
+}
+
+// Rewrites the file names of the profile, by prefix or by regular expression
+type pathMapping struct {
+	From   string `yaml:"from"`
+	To     string `yaml:"to"`
+	Regexp bool   `yaml:"regexp,omitempty"`
+}
+
+// OpenMetrics output configuration
+type metricsConfig struct {
+	Prefix string            `yaml:"prefix,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// Coverage bands used to color the table: red below Red and yellow below Yellow
+type colorConfig struct {
+	Metric string  `yaml:"metric,omitempty" schema:"enum=block|stmt"`
+	Red    float64 `yaml:"red,omitempty" schema:"minimum=0,maximum=100"`
+	Yellow float64 `yaml:"yellow,omitempty" schema:"minimum=0,maximum=100"`
 }
 
 // Parser arguments
 func init() {
-	flag.StringVar(&args.coverprofile, "coverprofile", "coverage.out", "Coverage output file")
-	flag.StringVar(&args.sortBy, "sort", "filename", "Column to sort by: filename, package, block, stmt, missing-blocks, missing-stmts")
-	flag.StringVar(&args.order, "order", "asc", "Sort order: asc, desc")
-	flag.Float64Var(&args.threshold, "threshold", 0, "Return an error if the coverage is below a threshold")
-	flag.StringVar(&args.metric, "metric", "block", "Use a specific metric for the threshold: block, stmt")
-	flag.BoolVar(&args.packages, "packages", false, "Report coverage per package instead of per file")
-	args.metricDefaulted = true
+	registerFlags(flag.CommandLine, &args)
+}
+
+// Defines the command line flags, storing their values in a set of
+// arguments
+func registerFlags(fs *flag.FlagSet, a *arguments) {
+	fs.StringVar(&a.coverprofile, "coverprofile", "coverage.out", "Coverage output file")
+	fs.StringVar(&a.config, "config", "", "Configuration file, by default "+configFile+" in the working directory or its parents")
+	fs.StringVar(&a.profile, "profile", "", "Use the settings of a profile of the configuration")
+	fs.StringVar(&a.sortBy, "sort", "filename", "Comma separated columns to sort by, each optionally followed by :asc or :desc: "+strings.Join(report.SortKeys(), ", "))
+	fs.StringVar(&a.order, "order", "asc", "Sort order of the columns without one: asc, desc")
+	fs.Float64Var(&a.threshold, "threshold", 0, "Return an error if the coverage is below a threshold")
+	fs.StringVar(&a.metric, "metric", "block", "Use a specific metric for the threshold: block, stmt")
+	fs.BoolVar(&a.packages, "packages", false, "Report coverage per package instead of per file")
+	fs.StringVar(&a.group, "group", "", "Group the files with a subtotal row for each group: package")
+	fs.StringVar(&a.format, "format", "table", "Output format: "+strings.Join(outputFormats, ", "))
+	fs.StringVar(&a.columns, "columns", "", "Comma separated columns to show: "+strings.Join(report.ColumnIDs(), ", "))
+	fs.BoolVar(&a.bar, "bar", false, "Add a bar chart of the coverage to the table")
+	fs.StringVar(&a.color, "color", "auto", "Color the table rows by coverage: auto, always, never")
+	fs.StringVar(&a.verifySources, "verify-sources", "", "Check the coverprofile against the source files and warn or fail on mismatches: warn, fail")
+	fs.BoolVar(&a.scanSources, "scan-sources", false, "Add the source files missing from the coverprofile as rows without coverage")
+	fs.StringVar(&a.tags, "tags", "", "With -scan-sources, comma separated build tags that select the source files")
+	fs.Float64Var(&a.below, "below", 0, "Show only the rows with a coverage below a value")
+	fs.Float64Var(&a.above, "above", 0, "Show only the rows with a coverage above a value")
+	fs.BoolVar(&a.untested, "untested", false, "Show only the rows without coverage")
+	fs.StringVar(&a.filterMetric, "filter-metric", "stmt", "Metric used by -below, -above and -untested: block, stmt")
+	fs.BoolVar(&a.filterTotal, "filter-total", false, "Compute the total from the rows left by -below, -above and -untested")
+	fs.IntVar(&a.top, "top", 0, "Show only the first N rows after sorting, aggregating the rest")
+	fs.IntVar(&a.minStmts, "min-stmts", 0, "Aggregate the rows with less than N statements")
+	fs.StringVar(&a.output, "output", "", "Write the report to a file instead of the standard output")
+	fs.BoolVar(&a.csvHeader, "csv-header", true, "With csv and tsv formats, print a header row")
+	fs.BoolVar(&a.csvTotal, "csv-total", false, "With csv and tsv formats, print the total as the last row")
+	fs.StringVar(&a.testsDir, "tests", "", "Directory with one coverage profile per test, reports which tests cover each file")
+	fs.StringVar(&a.coveredBy, "covered-by", "", "With -tests, list the tests that cover a location (file:line)")
+	fs.StringVar(&a.junit, "junit", "", "Write the threshold results to a JUnit XML file")
+	fs.StringVar(&a.result, "result", "", "Write the outcome of the checks and the exit code to a JSON file")
+	fs.BoolVar(&a.watch, "watch", false, "Render the report again whenever the coverprofile changes")
+	fs.StringVar(&a.addr, "addr", "localhost:8080", "With serve, address to listen on")
+	fs.StringVar(&a.token, "token", "", "With serve, token required to upload coverprofiles")
+	fs.StringVar(&a.watchCommand, "watch-command", "", "With -watch, command to run when the sources change, e.g. \"go test -coverprofile=coverage.out ./...\"")
 }
 
 func parseArguments() {
 	flag.Parse()
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "metric" {
-			args.metricDefaulted = false
+	// The first positional argument is a subcommand, which may be followed by more flags
+	args.command = ""
+	if flag.NArg() > 0 {
+		args.command = flag.Arg(0)
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+	}
+}
+
+// Sets the flags that weren't given on the command line from the
+// configuration, so that flags take precedence over the environment and the
+// configuration, and these over the defaults
+func applyConfig(config configuration, fs *flag.FlagSet, a *arguments) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	v := reflect.ValueOf(config)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("flag")
+		field := v.Field(i)
+		if name == "" || set[name] || field.IsZero() {
+			continue
+		}
+		var value string
+		switch field.Kind() {
+		case reflect.Slice:
+			value = strings.Join(field.Interface().([]string), ",")
+		case reflect.Ptr:
+			value = fmt.Sprint(field.Elem().Interface())
+		default:
+			value = fmt.Sprint(field.Interface())
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("Invalid value '%s' for %s in the configuration: %s", value, name, err)
+		}
+	}
+	// Rows are only filtered by -below and -above if they are given
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "below":
+			a.belowSet = true
+		case "above":
+			a.aboveSet = true
 		}
 	})
+	return nil
 }
 
 func main() {
 
 	// Parse arguments
 	parseArguments()
-	config, err := loadConfig(configFile)
+	var results checkResults
+	config, sources, err := resolveConfig(args.config, args.profile, os.LookupEnv)
+	args.sourceDir = findSourceDir(".", args.config)
+	if err == nil {
+		err = applyConfig(config, flag.CommandLine, &args)
+	}
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		err = &configFailure{err}
+	}
+	if err == nil {
+		switch args.command {
+		case "":
+			results, err = runToOutput(config, args)
+		case "config":
+			err = printConfig(config, sources, os.Stdout)
+		case "schema":
+			err = printSchema(os.Stdout)
+		case "tui":
+			err = runTUI(config, args)
+		case "serve":
+			err = runServe(config, args)
+		default:
+			err = fmt.Errorf("Unknown command '%s', use 'config', 'schema', 'tui', 'serve' or no command", args.command)
+		}
+	}
+	code := exitCode(results, err)
+	if args.result != "" {
+		if resultErr := writeResult(args.result, results, err, code); resultErr != nil {
+			fmt.Fprintln(os.Stderr, resultErr)
+			if code == exitOK {
+				code = exitError
+			}
+		}
+	}
+	// The failed policy rules have already been printed
+	if err != nil && code != exitPolicy {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+// Runs the command, writing the report to the output file if there's one
+func runToOutput(config configuration, args arguments) (checkResults, error) {
+	if args.output == "" {
+		return run(config, args, os.Stdout)
 	}
-	passed, err := run(config, args, os.Stdout)
+	// #nosec G304 -- the output file is chosen by the user
+	file, err := os.Create(args.output)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		return nil, err
 	}
-	if !passed {
-		os.Exit(1)
+	results, err := run(config, args, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
 	}
+	return results, err
 }
 
-// Runs the command
-func run(config configuration, args arguments, writer io.Writer) (bool, error) {
+// Runs the command and returns the results of the threshold and policy checks
+func run(config configuration, args arguments, writer io.Writer) (checkResults, error) {
 
-	// Use config values if arguments aren't set
-	var metric string
-	var threshold float64
-	if args.metricDefaulted && config.Metric != "" {
-		metric = config.Metric
-	} else {
-		metric = args.metric
+	if args.testsDir != "" {
+		return nil, runAttribution(config, args, writer)
 	}
-	if args.threshold == 0 {
-		threshold = config.Threshold
-	} else {
-		threshold = args.threshold
+	if args.watch {
+		return nil, runWatch(config, args, writer, nil)
+	}
+
+	rep, _, err := generateReport(config, args)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := verifySources(config, args, statusWriter(args, writer))
+	if err != nil {
+		return nil, err
+	}
+	if args.scanSources {
+		rep, err = report.AddUntestedSources(rep, args.sourceDir, config.Root, buildTags(args.tags), config.Exclusions, args.sortBy, args.order, args.packages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The filters only change what is shown, the checks apply to the whole report
+	shown, err := filterReport(rep, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = printReport(shown, writer, config, args); err != nil {
+		return nil, err
+	}
+	for _, output := range config.Outputs {
+		if err = writeOutput(shown, output, config, args); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := evaluateThresholds(args.threshold, rep, args.metric)
+	if err != nil {
+		return nil, err
+	}
+	policyResults, err := evaluatePolicy(config.Policy, rep)
+	if err != nil {
+		return nil, err
+	}
+	if args.junit != "" {
+		if err := writeJUnit(args.junit, append(results, policyResults...)); err != nil {
+			return nil, err
+		}
+	}
+	results = append(results, policyResults...)
+	if failed := printPolicy(policyResults, statusWriter(args, writer)); failed > 0 {
+		return results, &policyFailure{failed}
+	}
+	if issues > 0 && args.verifySources == "fail" {
+		return results, &sourcesFailure{issues}
+	}
+	return results, nil
+}
+
+// Generates the report of the coverprofile, with the functions of the
+// sources found in the module. Returns the blocks of the profile as well.
+func generateReport(config configuration, args arguments) (report.Report, map[string]*report.FileBlocks, error) {
+	rep, err := report.GenerateReport(args.coverprofile, config.Root, pathMappings(config), config.Exclusions, args.sortBy, args.order, args.packages)
+	if err != nil {
+		return rep, nil, err
+	}
+	blocks, err := report.LoadFileBlocks(args.coverprofile, args.sourceDir, config.Root, pathMappings(config), config.Exclusions)
+	if err != nil {
+		return rep, nil, err
+	}
+	rep, err = report.AddFunctions(rep, blocks, config.Root, args.packages)
+	return rep, blocks, err
+}
+
+// Whether a format is a table for people to read
+func isTable(format string) bool {
+	return format == "table" || format == "" || format == "html"
+}
+
+// Writer for status messages. They go along with the report when it's
+// a table, and to the standard error otherwise, not to mix them with
+// machine readable outputs.
+func statusWriter(args arguments, writer io.Writer) io.Writer {
+	if args.format == "table" || args.format == "" {
+		return writer
+	}
+	return os.Stderr
+}
+
+// The coverprofile doesn't match the source files
+type sourcesFailure struct {
+	issues int
+}
+
+func (e *sourcesFailure) Error() string {
+	return fmt.Sprintf("%d problems found checking the coverprofile against the sources", e.issues)
+}
+
+// Checks the coverprofile against the source files if requested, printing
+// the problems found. Returns the number of mismatches, which leave out the
+// stale files.
+func verifySources(config configuration, args arguments, w io.Writer) (int, error) {
+	var label string
+	switch args.verifySources {
+	case "":
+		return 0, nil
+	case "warn":
+		label = "WARN"
+	case "fail":
+		label = "FAIL"
+	default:
+		return 0, fmt.Errorf("Invalid source verification '%s', use 'warn' or 'fail'", args.verifySources)
+	}
+	blocks, err := report.LoadFileBlocks(args.coverprofile, args.sourceDir, config.Root, pathMappings(config), config.Exclusions)
+	if err != nil {
+		return 0, err
+	}
+	issues, err := report.VerifySources(args.coverprofile, blocks)
+	if err != nil {
+		return 0, err
+	}
+	mismatches := 0
+	for _, issue := range issues {
+		// Stale files are only a warning, their times may have changed alone
+		issueLabel := "WARN"
+		if issue.Mismatch() {
+			issueLabel = label
+			mismatches++
+		}
+		fmt.Fprintf(w, "%s %s %s: %s\n", issueLabel, issue.Kind, issue.File, issue.Message)
+	}
+	return mismatches, nil
+}
+
+// Applies the row filters to the report. The total only changes if
+// filterTotal is set.
+func filterReport(rep report.Report, args arguments) (report.Report, error) {
+	if args.belowSet || args.aboveSet || args.untested {
+		if _, err := report.Metric(rep.Total, args.filterMetric); err != nil {
+			return rep, err
+		}
+		rep = report.Filter(rep, func(s report.Summary) bool {
+			coverage, _ := report.Metric(s, args.filterMetric)
+			return (!args.belowSet || coverage < args.below) &&
+				(!args.aboveSet || coverage > args.above) &&
+				(!args.untested || coverage == 0)
+		}, args.filterTotal)
+	}
+	return rep, nil
+}
+
+// Columns of the tabular outputs, taken from the arguments or the configuration
+func tableColumns(config configuration, args arguments) ([]report.Column, error) {
+	if args.columns != "" {
+		return report.ParseColumns(strings.Split(args.columns, ","))
+	}
+	return report.ParseColumns(config.Columns)
+}
+
+// Build tags of a comma separated list, as in "go build -tags"
+func buildTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Path mappings of the configuration
+func pathMappings(config configuration) []report.PathMapping {
+	mappings := make([]report.PathMapping, 0, len(config.PathMappings))
+	for _, m := range config.PathMappings {
+		mappings = append(mappings, report.PathMapping{From: m.From, To: m.To, Regexp: m.Regexp})
+	}
+	return mappings
+}
+
+// Coverage bands of the configuration, with defaults for the missing values
+func colorBands(config configuration) report.ColorBands {
+	bands := report.DefaultColorBands
+	if config.Colors.Metric != "" {
+		bands.Metric = config.Colors.Metric
+	}
+	if config.Colors.Red != 0 {
+		bands.Red = config.Colors.Red
+	}
+	if config.Colors.Yellow != 0 {
+		bands.Yellow = config.Colors.Yellow
 	}
+	return bands
+}
+
+// Whether to color the output. In auto mode, colors are used only on a
+// terminal and if NO_COLOR isn't set.
+func useColor(mode string, writer io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	file, ok := writer.(*os.File)
+	return ok && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(file.Fd()))
+}
 
-	rep, err := report.GenerateReport(args.coverprofile, config.Root, config.Exclusions, args.sortBy, args.order, args.packages)
+// Writes the report to an additional output file, without colors
+func writeOutput(rep report.Report, output outputConfig, config configuration, args arguments) error {
+	args.format, args.color = output.Format, "never"
+	// #nosec G304 -- the output file is chosen by the user
+	file, err := os.Create(output.Output)
 	if err != nil {
-		return false, err
+		return err
 	}
+	err = printReport(rep, file, config, args)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Groups the files of the report as requested, or returns nil if they
+// aren't grouped
+func groupReport(rep report.Report, args arguments) (*report.GroupedReport, error) {
+	switch args.group {
+	case "":
+		return nil, nil
+	case "package":
+		if args.packages {
+			return nil, fmt.Errorf("Can't group a report by package, remove -packages to group its files")
+		}
+		grouped, err := report.GroupByPackage(rep, args.sortBy, args.order)
+		return &grouped, err
+	default:
+		return nil, fmt.Errorf("Invalid group '%s', use 'package'", args.group)
+	}
+}
 
-	if err = report.PrintTable(rep, writer, args.packages); err != nil {
-		return false, err
+// Formats of the report
+var outputFormats = []string{"table", "json", "html", "openmetrics", "sonar", "csv", "tsv"}
+
+// Prints the report in the requested format. extra options apply to the
+// table and html formats.
+func printReport(rep report.Report, writer io.Writer, config configuration, args arguments, extra ...report.TableOption) error {
+	columns, err := tableColumns(config, args)
+	if err != nil {
+		return err
 	}
+	// The Others row of Top isn't a file, so only the tables for people to
+	// read get it; the other outputs keep every row
+	if isTable(args.format) && (args.top > 0 || args.minStmts > 0) {
+		rep = report.Top(rep, args.top, args.minStmts)
+	}
+	grouped, err := groupReport(rep, args)
+	if err != nil {
+		return err
+	}
+	tableOpts := append([]report.TableOption{report.WithColumns(columns)}, extra...)
+	if grouped != nil {
+		tableOpts = append(tableOpts, report.WithGroups(grouped.Groups))
+	}
+	switch {
+	case grouped == nil:
+	case args.format == "json":
+		return report.PrintJSON(grouped, writer)
+	case args.format == "openmetrics" || args.format == "sonar":
+		return fmt.Errorf("Format '%s' can't group the files, use 'table', 'json', 'html', 'csv' or 'tsv'", args.format)
+	}
+	switch args.format {
+	case "table", "":
+		bands := colorBands(config)
+		opts := tableOpts
+		if args.bar {
+			opts = append(opts, report.WithBar(bands.Metric, 20))
+		}
+		if useColor(args.color, writer) {
+			opts = append(opts, report.WithColors(bands))
+		}
+		return report.PrintTable(rep, writer, args.packages, opts...)
+	case "json":
+		return report.PrintJSON(rep, writer)
+	case "html":
+		return report.PrintHTML(rep, writer, args.packages, tableOpts...)
+	case "openmetrics":
+		return report.PrintOpenMetrics(rep, writer, report.MetricsOptions{
+			Prefix:   config.OpenMetrics.Prefix,
+			Labels:   config.OpenMetrics.Labels,
+			Packages: args.packages})
+	case "sonar":
+		blocks, err := report.LoadFileBlocks(args.coverprofile, args.sourceDir, config.Root, pathMappings(config), config.Exclusions)
+		if err != nil {
+			return err
+		}
+		return report.PrintSonar(blocks, writer, args.sourceDir)
+	case "csv", "tsv":
+		opts := report.CSVOptions{Comma: ',', Header: args.csvHeader, Total: args.csvTotal, Packages: args.packages, Columns: columns}
+		if grouped != nil {
+			opts.Groups = grouped.Groups
+		}
+		if args.format == "tsv" {
+			opts.Comma = '\t'
+		}
+		return report.PrintCSV(rep, writer, opts)
+	default:
+		return fmt.Errorf("Invalid format '%s', use 'table', 'json', 'html', 'openmetrics', 'sonar', 'csv' or 'tsv'", args.format)
+	}
+}
 
-	passed, err := checkThreshold(threshold, rep.Total, metric)
+// Opens the interactive coverage browser
+func runTUI(config configuration, args arguments) error {
+	args.packages = false
+	rep, blocks, err := generateReport(config, args)
 	if err != nil {
-		return false, err
+		return err
 	}
-	return passed, nil
+	return tui.Run(tui.NewModel(rep, blocks, args.sortBy, args.order), os.Stdin, os.Stdout)
 }
 
-// Loads the report configuration from a yml file
-func loadConfig(filename string) (configuration, error) {
-	conf := configuration{Exclusions: []string{}}
-	// #nosec G304 -- reads fixed local config file ".goverreport.yaml"
-	data, err := os.ReadFile(filename)
+// Reports which tests cover a location, or which files are covered by a single test
+// if no location is given
+func runAttribution(config configuration, args arguments, writer io.Writer) error {
+	attribution, err := report.LoadAttribution(args.testsDir, config.Root, pathMappings(config), config.Exclusions)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return conf, err
+		return err
+	}
+	var result []report.FileTests
+	if args.coveredBy != "" {
+		fileName, line, err := report.ParseLocation(args.coveredBy)
+		if err != nil {
+			return err
 		}
-	} else {
-		if err := yaml.Unmarshal(data, &conf); err != nil {
-			return configuration{}, err
+		tests, err := attribution.TestsCovering(fileName, line)
+		if err != nil {
+			return err
 		}
+		result = []report.FileTests{{Name: args.coveredBy, Tests: tests}}
+	} else {
+		result = attribution.SingleTestFiles()
+	}
+	switch args.format {
+	case "table", "":
+		return report.PrintFileTests(result, writer)
+	case "json":
+		return report.PrintJSON(result, writer)
+	default:
+		return fmt.Errorf("Invalid format '%s', use 'table' or 'json'", args.format)
 	}
-	return conf, nil
 }
 
 // Checks whether the coverage is above a threshold value.
@@ -2,16 +2,22 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mcubik/goverreport/report"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadConfiguration(t *testing.T) {
 	assert := assert.New(t)
-	conf, err := loadConfig(".goverreport.yml")
+	conf, _, err := resolveConfig(".goverreport.yml", "", noEnv)
 	assert.NoError(err)
 	assert.Equal(conf, configuration{
 		Root:       "github.com/mcubik/goverreport",
@@ -22,7 +28,7 @@ func TestLoadConfiguration(t *testing.T) {
 
 func TestEmptyConfig(t *testing.T) {
 	assert := assert.New(t)
-	conf, err := loadConfig("emptyconfig.yml")
+	conf, _, err := resolveConfig("emptyconfig.yml", "", noEnv)
 	assert.NoError(err)
 	assert.Equal(conf, configuration{
 		Root:       "",
@@ -33,7 +39,11 @@ func TestEmptyConfig(t *testing.T) {
 
 func TestEmptyConfigWhenFileMissing(t *testing.T) {
 	assert := assert.New(t)
-	conf, err := loadConfig("xxxxxx.yml")
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { require.NoError(t, os.Chdir(wd)) }()
+	conf, _, err := resolveConfig("", "", noEnv)
 	assert.NoError(err)
 	assert.Equal(conf, configuration{
 		Root:       "",
@@ -90,9 +100,9 @@ func TestRun(t *testing.T) {
 		sortBy:       "filename",
 		order:        "asc"}
 	buf := bytes.Buffer{}
-	passed, err := run(configuration{}, args, &buf)
+	results, err := run(configuration{}, args, &buf)
 	assert.NoError(err)
-	assert.False(passed)
+	assert.False(results.passed())
 	assert.Contains(buf.String(), "Total", "Table generated")
 }
 
@@ -105,9 +115,9 @@ func TestRunAboveThreshold(t *testing.T) {
 		sortBy:       "filename",
 		order:        "asc"}
 	buf := bytes.Buffer{}
-	passed, err := run(configuration{}, args, &buf)
+	results, err := run(configuration{}, args, &buf)
 	assert.NoError(err)
-	assert.True(passed)
+	assert.True(results.passed())
 }
 
 func TestRunFailInvalidArugment(t *testing.T) {
@@ -122,35 +132,42 @@ func TestRunFailInvalidArugment(t *testing.T) {
 	assert.Error(err)
 }
 
+// Parses a command line with the configuration applied to the flags that aren't given
+func parseWithConfig(t *testing.T, config configuration, commandLine ...string) arguments {
+	var a arguments
+	fs := flag.NewFlagSet("goverreport", flag.ContinueOnError)
+	registerFlags(fs, &a)
+	require.NoError(t, fs.Parse(commandLine))
+	require.NoError(t, applyConfig(config, fs, &a))
+	return a
+}
+
 func TestTakesConfigurationIfNotOverridenByCommandLineArgs(t *testing.T) {
 	assert := assert.New(t)
 	config := configuration{Threshold: 80, Metric: "stmt"}
-	args := arguments{
-		coverprofile:    "sample_coverage.out",
-		threshold:       0,
-		metric:          "block",
-		sortBy:          "filename",
-		order:           "asc",
-		metricDefaulted: true}
+	args := parseWithConfig(t, config, "-coverprofile=sample_coverage.out")
 	buf := bytes.Buffer{}
-	passed, err := run(config, args, &buf)
+	results, err := run(config, args, &buf)
 	assert.NoError(err)
-	assert.True(passed) // Passes stmt coverage
+	assert.Len(results, 1)
+	assert.True(results.passed()) // Passes stmt coverage
 }
 
 func TestCommandLineArgsOverridesConfiguration(t *testing.T) {
 	assert := assert.New(t)
 	config := configuration{Threshold: 80, Metric: "block"}
-	args := arguments{
-		coverprofile: "sample_coverage.out",
-		threshold:    0,
-		metric:       "stmt",
-		sortBy:       "filename",
-		order:        "asc"}
+	args := parseWithConfig(t, config, "-coverprofile=sample_coverage.out", "-metric=stmt")
 	buf := bytes.Buffer{}
-	passed, err := run(config, args, &buf)
+	results, err := run(config, args, &buf)
+	assert.NoError(err)
+	assert.Len(results, 1)
+	assert.True(results.passed()) // Passes stmt coverage
+
+	// A zero threshold can be forced from the command line
+	args = parseWithConfig(t, config, "-coverprofile=sample_coverage.out", "-threshold=0")
+	results, err = run(config, args, &buf)
 	assert.NoError(err)
-	assert.True(passed) // Passes stmt coverage
+	assert.Empty(results)
 }
 
 func TestMetricArgument(t *testing.T) {
@@ -159,13 +176,54 @@ func TestMetricArgument(t *testing.T) {
 	// Metric default value
 	parseArguments()
 	assert.Equal("block", args.metric)
-	assert.True(args.metricDefaulted)
 
 	// Metric set
 	os.Args[1] = "-metric=stmt"
 	parseArguments()
 	assert.Equal("stmt", args.metric)
-	assert.False(args.metricDefaulted)
+}
+
+func TestApplyConfig(t *testing.T) {
+	assert := assert.New(t)
+	header := false
+	config := configuration{
+		Columns:      []string{"name", "stmt"},
+		WatchCommand: "go test ./...",
+		Sort:         "stmt",
+		Order:        "desc",
+		Packages:     true,
+		Below:        50,
+		Top:          5,
+		CSVHeader:    &header}
+	args := parseWithConfig(t, config, "-order=asc", "-above=10")
+	assert.Equal("name,stmt", args.columns)
+	assert.Equal("go test ./...", args.watchCommand)
+	assert.Equal("stmt", args.sortBy)
+	assert.Equal("asc", args.order, "Flags take precedence")
+	assert.True(args.packages)
+	assert.Equal(50.0, args.below)
+	assert.True(args.belowSet)
+	assert.True(args.aboveSet)
+	assert.Equal(5, args.top)
+	assert.False(args.csvHeader)
+	assert.Equal("coverage.out", args.coverprofile, "Defaults are kept")
+
+	args = parseWithConfig(t, configuration{})
+	assert.False(args.belowSet)
+	assert.True(args.csvHeader)
+}
+
+func TestEveryFlagIsConfigurable(t *testing.T) {
+	configured := make(map[string]bool)
+	config := reflect.TypeOf(configuration{})
+	for i := 0; i < config.NumField(); i++ {
+		configured[config.Field(i).Tag.Get("flag")] = true
+	}
+	fs := flag.NewFlagSet("goverreport", flag.ContinueOnError)
+	registerFlags(fs, &arguments{})
+	fs.VisitAll(func(f *flag.Flag) {
+		assert.True(t, configured[f.Name] || f.Name == "config" || f.Name == "profile", f.Name)
+	})
 }
 
 func TestRunPackages(t *testing.T) {
@@ -176,9 +234,9 @@ func TestRunPackages(t *testing.T) {
 		sortBy:       "package",
 		order:        "asc"}
 	buf := bytes.Buffer{}
-	passed, err := run(configuration{}, args, &buf)
+	results, err := run(configuration{}, args, &buf)
 	assert.NoError(err)
-	assert.True(passed)
+	assert.True(results.passed())
 	assert.Contains(buf.String(), "Package", "Column title is package")
 	assert.Contains(buf.String(), "| github.com/mcubik/goverreport ", "Package .")
 	assert.Contains(buf.String(), "| github.com/mcubik/goverreport/report |", "Package ./report")
@@ -195,10 +253,419 @@ func TestRunPackagesWithRoot(t *testing.T) {
 		sortBy:       "package",
 		order:        "asc"}
 	buf := bytes.Buffer{}
-	passed, err := run(config, args, &buf)
+	results, err := run(config, args, &buf)
 	assert.NoError(err)
-	assert.True(passed)
+	assert.True(results.passed())
 	assert.Contains(buf.String(), "Package", "Column title is package")
 	assert.Contains(buf.String(), "| . ", "Package .")
 	assert.Contains(buf.String(), "| ./report |", "Package ./report")
 }
+
+func TestRunJSON(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "json"}
+	buf := bytes.Buffer{}
+	results, err := run(configuration{}, args, &buf)
+	assert.NoError(err)
+	assert.True(results.passed())
+	assert.Contains(buf.String(), `"name": "Total"`)
+	assert.Contains(buf.String(), `"blocks": 81`)
+}
+
+func TestRunInvalidFormat(t *testing.T) {
+	_, err := run(configuration{}, arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "xxx"},
+		new(bytes.Buffer))
+	assert.Error(t, err)
+}
+
+func TestRunAttribution(t *testing.T) {
+	assert := assert.New(t)
+	config := configuration{Root: "github.com/mcubik/goverreport"}
+	buf := bytes.Buffer{}
+	results, err := run(config, arguments{testsDir: "report/testdata/tests"}, &buf)
+	assert.NoError(err)
+	assert.True(results.passed())
+	assert.Contains(buf.String(), "| /report/view.go | TestSortByFileName |")
+
+	buf.Reset()
+	_, err = run(config, arguments{
+		testsDir:  "report/testdata/tests",
+		coveredBy: "report/report.go:60",
+		format:    "json"}, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), `"TestReport",`)
+	assert.Contains(buf.String(), `"TestSortByFileName"`)
+
+	_, err = run(config, arguments{testsDir: "report/testdata/tests", coveredBy: "xxx"}, &buf)
+	assert.Error(err)
+	_, err = run(config, arguments{testsDir: "report/testdata/tests", format: "xxx"}, &buf)
+	assert.Error(err)
+	_, err = run(config, arguments{testsDir: "xxx"}, &buf)
+	assert.Error(err)
+}
+
+func TestRunHTML(t *testing.T) {
+	buf := bytes.Buffer{}
+	_, err := run(configuration{}, arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "html"}, &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "<html>")
+}
+
+func TestRunOpenMetrics(t *testing.T) {
+	buf := bytes.Buffer{}
+	config := configuration{
+		Root:        "github.com/mcubik/goverreport",
+		OpenMetrics: metricsConfig{Prefix: "goverreport", Labels: map[string]string{"repo": "goverreport"}}}
+	_, err := run(config, arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "openmetrics"}, &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `goverreport_blocks{repo="goverreport",package="/report",file="/report/view.go"} 4`)
+}
+
+func TestRunSonar(t *testing.T) {
+	buf := bytes.Buffer{}
+	args := arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", format: "sonar"}
+	_, err := run(configuration{}, args, &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<file path="report/view.go">`, "Relative to the module")
+
+	buf.Reset()
+	config := configuration{PathMappings: []pathMapping{{From: "github.com/mcubik/goverreport/", To: "src/"}}}
+	_, err = run(config, args, &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<file path="src/report/view.go">`, "Named after the path mappings")
+
+	args.coverprofile = "xxx.out"
+	assert.Error(t, printReport(report.Report{}, &buf, config, args))
+}
+
+func TestRunCSVToOutput(t *testing.T) {
+	assert := assert.New(t)
+	output := filepath.Join(t.TempDir(), "coverage.tsv")
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "tsv",
+		output:       output,
+		csvHeader:    true,
+		csvTotal:     true}
+	results, err := runToOutput(configuration{Root: "github.com/mcubik/goverreport"}, args)
+	assert.NoError(err)
+	assert.True(results.passed())
+	data, err := os.ReadFile(output)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(lines, 5)
+	assert.Equal("file\tblocks\tmissingBlocks\tstmts\tmissingStmts\tblockCoverage\tstmtCoverage", lines[0])
+	assert.Equal("/report/view.go\t4\t0\t7\t0\t100\t100", lines[3])
+	assert.True(strings.HasPrefix(lines[4], "Total\t81\t15\t111\t20\t81.48148148148"))
+
+	args.format = "csv"
+	args.output = filepath.Join(t.TempDir(), "xxx", "coverage.csv")
+	_, err = runToOutput(configuration{}, args)
+	assert.Error(err)
+}
+
+func TestRunColumns(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "csv",
+		csvHeader:    true}
+	buf := bytes.Buffer{}
+	_, err := run(configuration{Columns: []string{"name", "stmt"}}, args, &buf)
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(buf.String(), "file,stmtCoverage\n"))
+
+	buf.Reset()
+	args.columns = "missing-stmts,name"
+	_, err = run(configuration{Columns: []string{"name", "stmt"}}, args, &buf)
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(buf.String(), "missingStmts,file\n"), "Argument overrides configuration")
+
+	args.columns = "xxx"
+	_, err = run(configuration{}, args, &buf)
+	assert.Error(err)
+}
+
+func TestRunBarAndColors(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		bar:          true,
+		color:        "always"}
+	config := configuration{
+		Root:   "github.com/mcubik/goverreport",
+		Colors: colorConfig{Metric: "block", Red: 70, Yellow: 90}}
+	buf := bytes.Buffer{}
+	_, err := run(config, args, &buf)
+	assert.NoError(err)
+	assert.Regexp(`Block cover\s+\|\n`, buf.String())
+	assert.Contains(buf.String(), "\x1b[31m/main.go")
+	assert.Contains(buf.String(), "\x1b[32m/report/view.go")
+	assert.Contains(buf.String(), "\x1b[33m/report/report.go")
+}
+
+func TestUseColor(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(useColor("always", new(bytes.Buffer)))
+	assert.False(useColor("never", os.Stdout))
+	assert.False(useColor("auto", new(bytes.Buffer)), "Not a terminal")
+	t.Setenv("NO_COLOR", "1")
+	assert.False(useColor("auto", os.Stdout))
+}
+
+func TestRunTop(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "missing-stmts",
+		order:        "desc",
+		format:       "csv",
+		csvTotal:     true,
+		top:          1}
+	buf := bytes.Buffer{}
+	_, err := run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 4, "Only the tables aggregate the rows")
+	assert.NotContains(buf.String(), "Others")
+
+	buf.Reset()
+	args.format = "table"
+	_, err = run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "| /main.go ")
+	assert.Contains(buf.String(), "| Others (2) ")
+	assert.NotContains(buf.String(), "/report/report.go")
+
+	buf.Reset()
+	args.format = "openmetrics"
+	_, err = run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	assert.NotContains(buf.String(), "Others")
+}
+
+func TestRunCoverageFilters(t *testing.T) {
+	assert := assert.New(t)
+	config := configuration{Root: "github.com/mcubik/goverreport"}
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "csv",
+		csvTotal:     true,
+		filterMetric: "stmt",
+		below:        95,
+		belowSet:     true,
+		above:        70,
+		aboveSet:     true}
+	buf := bytes.Buffer{}
+	_, err := run(config, args, &buf)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 2)
+	assert.True(strings.HasPrefix(lines[0], "/report/report.go,"))
+	assert.True(strings.HasPrefix(lines[1], "Total,81,15,111,20,"), "Total isn't filtered")
+
+	buf.Reset()
+	args.filterTotal = true
+	_, err = run(config, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Total,47,5,60,5,")
+
+	buf.Reset()
+	args = arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", format: "csv", untested: true, filterMetric: "block"}
+	_, err = run(config, args, &buf)
+	assert.NoError(err)
+	assert.Equal("", buf.String(), "Every file has some coverage")
+
+	args = arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", format: "json",
+		filterMetric: "stmt", below: 10, belowSet: true, filterTotal: true}
+	_, err = run(config, args, &buf)
+	assert.NoError(err, "An empty selection has a valid total")
+	assert.Contains(buf.String(), `"stmtCoverage": 100`)
+
+	args.threshold, args.metric = 85, "stmt"
+	results, err := run(config, args, &buf)
+	assert.NoError(err)
+	assert.False(results.passed(), "The threshold is checked on the whole report")
+	config.Policy = []policyRule{{Condition: "stmt >= 90"}}
+	_, err = run(config, args, &buf)
+	assert.IsType(&policyFailure{}, err, "The policy is checked on the whole report")
+	config.Policy = nil
+
+	args.filterMetric = "xxx"
+	_, err = run(config, args, &buf)
+	assert.Error(err)
+}
+
+func TestRunScanSources(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{
+		coverprofile: "sample_coverage.out",
+		sortBy:       "filename",
+		order:        "asc",
+		format:       "csv",
+		csvTotal:     true,
+		scanSources:  true,
+		sourceDir:    "."}
+	buf := bytes.Buffer{}
+	_, err := run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "\n/main.go,30,10,44,15,", "Files in the profile are kept")
+	assert.Regexp(`\n/tui/tui.go,\d+,\d+,\d+,\d+,0,0\n`, buf.String())
+	assert.NotContains(buf.String(), "Total,81,")
+}
+
+func TestRunFromSubdirectory(t *testing.T) {
+	assert := assert.New(t)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir("report"))
+	defer func() { require.NoError(t, os.Chdir(wd)) }()
+
+	args := arguments{
+		coverprofile:  "../sample_coverage.out",
+		sortBy:        "filename",
+		order:         "asc",
+		format:        "csv",
+		scanSources:   true,
+		verifySources: "warn",
+		sourceDir:     findSourceDir(".", "")}
+	assert.Equal("..", args.sourceDir, "The module is in the parent directory")
+	buf := bytes.Buffer{}
+	_, err = run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	assert.Equal(1, strings.Count(buf.String(), "\n/main.go,"), "Files in the profile aren't added again")
+	assert.Contains(buf.String(), "\n/tui/tui.go,")
+
+	buf.Reset()
+	args.format, args.scanSources = "table", false
+	_, err = run(configuration{Root: "github.com/mcubik/goverreport"}, args, &buf)
+	assert.NoError(err)
+	assert.NotContains(buf.String(), "source file not found", "Sources are found in the module directory")
+}
+
+func TestBuildTags(t *testing.T) {
+	assert.Equal(t, []string{"integration", "linux"}, buildTags(" integration,,linux "))
+	assert.Nil(t, buildTags(""))
+}
+
+func TestRunVerifySources(t *testing.T) {
+	assert := assert.New(t)
+	config := configuration{Root: "example.com/mod"}
+	args := arguments{coverprofile: "report/testdata/module.out", sortBy: "filename", order: "asc", verifySources: "warn"}
+	buf := bytes.Buffer{}
+	_, err := run(config, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "WARN missing /a.go: source file not found\n")
+
+	args.verifySources = "fail"
+	buf.Reset()
+	_, err = run(config, args, &buf)
+	assert.Equal(exitSources, exitCode(nil, err))
+	assert.Contains(buf.String(), "FAIL missing /a.go: source file not found\n")
+
+	args.verifySources = "xxx"
+	_, err = run(config, args, &buf)
+	assert.Error(err)
+
+	// A source newer than the profile, as after a checkout, still matches it
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/mod\n")
+	writeFile(t, filepath.Join(dir, "a.go"), "package mod\n\nfunc A() int {\n\treturn 1\n}\n")
+	args.coverprofile = filepath.Join(dir, "coverage.out")
+	writeFile(t, args.coverprofile, "mode: set\nexample.com/mod/a.go:3.14,5.2 1 1\n")
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(args.coverprofile, past, past))
+	args.verifySources, args.sourceDir = "fail", dir
+	buf.Reset()
+	_, err = run(config, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "WARN stale /a.go: source file modified after the coverprofile\n")
+}
+
+func TestRunOutputs(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	config := configuration{Outputs: []outputConfig{
+		{Format: "json", Output: filepath.Join(dir, "coverage.json")},
+		{Format: "csv", Output: filepath.Join(dir, "coverage.csv")}}}
+	args := arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", color: "always", csvHeader: true}
+	buf := bytes.Buffer{}
+	_, err := run(config, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Total")
+	data, err := os.ReadFile(filepath.Join(dir, "coverage.json"))
+	assert.NoError(err)
+	assert.Contains(string(data), `"total"`)
+	data, err = os.ReadFile(filepath.Join(dir, "coverage.csv"))
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(data), "file,"))
+
+	config.Outputs = []outputConfig{{Format: "json", Output: filepath.Join(dir, "xxx", "coverage.json")}}
+	_, err = run(config, args, &buf)
+	assert.Error(err)
+}
+
+func TestRunGroup(t *testing.T) {
+	assert := assert.New(t)
+	args := arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", group: "package"}
+	buf := bytes.Buffer{}
+	_, err := run(configuration{}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Subtotal github.com/mcubik/goverreport/report")
+
+	buf.Reset()
+	args.format = "json"
+	_, err = run(configuration{}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), `"packages"`)
+
+	buf.Reset()
+	args.format, args.top = "csv", 2
+	_, err = run(configuration{}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "\nSubtotal github.com/mcubik/goverreport,")
+	assert.NotContains(buf.String(), "Others")
+
+	buf.Reset()
+	args.format = "html"
+	_, err = run(configuration{}, args, &buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "<td>Others (1)</td>")
+	assert.NotContains(buf.String(), "Subtotal .", "Others isn't grouped")
+
+	args.format, args.top = "sonar", 0
+	_, err = run(configuration{}, args, &buf)
+	assert.EqualError(err, "Format 'sonar' can't group the files, use 'table', 'json', 'html', 'csv' or 'tsv'")
+	args.format = "table"
+	args.group = "dir"
+	_, err = run(configuration{}, args, &buf)
+	assert.EqualError(err, "Invalid group 'dir', use 'package'")
+	args.group = "package"
+	args.packages = true
+	_, err = run(configuration{}, args, &buf)
+	assert.Error(err)
+}
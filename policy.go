@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mcubik/goverreport/report"
+)
+
+// Outcome of checking a condition on a row of the report
+type thresholdResult struct {
+	Rule      string  `json:"rule"`      // Name of the rule, "threshold" for the global threshold
+	Name      string  `json:"name"`      // Row that was checked, e.g. "Total"
+	Metric    string  `json:"metric"`    // Value compared: block, stmt, missing-stmts...
+	Op        string  `json:"op"`        // Comparison operator
+	Threshold float64 `json:"threshold"` // Required value
+	Value     float64 `json:"value"`     // Actual value
+	Passed    bool    `json:"passed"`
+}
+
+// Results of all the checks of a run
+type checkResults []thresholdResult
+
+// Whether every check passed
+func (r checkResults) passed() bool {
+	for _, result := range r {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// A named condition of the coverage policy, e.g. "stmt >= 80". The
+// condition applies to the total, or to every row if the scope is files.
+type policyRule struct {
+	Name      string `yaml:"name,omitempty"`
+	Condition string `yaml:"condition"`
+	Scope     string `yaml:"scope,omitempty" schema:"enum=total|files"`
+}
+
+// Policy rule that fails if any condition isn't met
+type policyFailure struct {
+	failed int
+}
+
+func (e *policyFailure) Error() string {
+	return fmt.Sprintf("%d policy rules failed", e.failed)
+}
+
+// Parsed condition of a policy rule
+type condition struct {
+	column    report.Column
+	op        string
+	threshold float64
+}
+
+var conditionPattern = regexp.MustCompile(`^\s*([a-z-]+)\s*(>=|<=|==|!=|>|<)\s*(-?[0-9.]+)\s*$`)
+
+// Parses a condition like "missing-stmts <= 500". The value can be any
+// numeric column.
+func parseCondition(text string) (condition, error) {
+	match := conditionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return condition{}, fmt.Errorf("Invalid condition '%s', use <metric> <operator> <value>, e.g. 'stmt >= 80'", text)
+	}
+	columns, err := report.ParseColumns([]string{match[1]})
+	if err != nil || !columns[0].Numeric {
+		return condition{}, fmt.Errorf("Invalid metric '%s' in condition '%s'", match[1], text)
+	}
+	threshold, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return condition{}, fmt.Errorf("Invalid value '%s' in condition '%s'", match[3], text)
+	}
+	return condition{columns[0], match[2], threshold}, nil
+}
+
+// Checks the condition on a summary and returns its outcome
+func (c condition) check(rule string, s report.Summary) thresholdResult {
+	value := c.column.Value(s)
+	var passed bool
+	switch c.op {
+	case ">=":
+		passed = value >= c.threshold
+	case "<=":
+		passed = value <= c.threshold
+	case ">":
+		passed = value > c.threshold
+	case "<":
+		passed = value < c.threshold
+	case "==":
+		passed = value == c.threshold
+	case "!=":
+		passed = value != c.threshold
+	}
+	return thresholdResult{
+		Rule:      rule,
+		Name:      s.Name,
+		Metric:    c.column.ID,
+		Op:        c.op,
+		Threshold: c.threshold,
+		Value:     value,
+		Passed:    passed}
+}
+
+// Checks the global threshold, which is skipped if it isn't set
+func evaluateThresholds(threshold float64, rep report.Report, metric string) ([]thresholdResult, error) {
+	if threshold <= 0 {
+		return nil, nil
+	}
+	passed, err := checkThreshold(threshold, rep.Total, metric)
+	if err != nil {
+		return nil, err
+	}
+	coverage, err := report.Metric(rep.Total, metric)
+	if err != nil {
+		return nil, err
+	}
+	return []thresholdResult{{
+		Rule:      "threshold",
+		Name:      rep.Total.Name,
+		Metric:    metric,
+		Op:        ">=",
+		Threshold: threshold,
+		Value:     coverage,
+		Passed:    passed}}, nil
+}
+
+// Checks every rule of the policy, returning a result for the total or
+// for every row, depending on the scope of the rule
+func evaluatePolicy(rules []policyRule, rep report.Report) ([]thresholdResult, error) {
+	var results []thresholdResult
+	for _, rule := range rules {
+		cond, err := parseCondition(rule.Condition)
+		if err != nil {
+			return nil, err
+		}
+		name := rule.Name
+		if name == "" {
+			name = rule.Condition
+		}
+		switch rule.Scope {
+		case "", "total":
+			results = append(results, cond.check(name, rep.Total))
+		case "files":
+			for _, s := range rep.Files {
+				results = append(results, cond.check(name, s))
+			}
+		default:
+			return nil, fmt.Errorf("Invalid scope '%s' in policy rule '%s', use 'total' or 'files'", rule.Scope, name)
+		}
+	}
+	return results, nil
+}
+
+// Prints a line for every rule telling whether it passed, with the rows
+// that failed. Returns the number of failed rules.
+func printPolicy(results []thresholdResult, w io.Writer) int {
+	var rules []string
+	failures := make(map[string][]string)
+	for _, r := range results {
+		if _, ok := failures[r.Rule]; !ok {
+			rules = append(rules, r.Rule)
+			failures[r.Rule] = []string{}
+		}
+		if !r.Passed {
+			failures[r.Rule] = append(failures[r.Rule], fmt.Sprintf("%s %s is %.2f", r.Name, r.Metric, r.Value))
+		}
+	}
+	failed := 0
+	for _, rule := range rules {
+		if len(failures[rule]) == 0 {
+			fmt.Fprintf(w, "PASS %s\n", rule)
+		} else {
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %s\n", rule, strings.Join(failures[rule], ", "))
+		}
+	}
+	return failed
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mcubik/goverreport/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateThresholds(t *testing.T) {
+	assert := assert.New(t)
+	rep := report.Report{Total: report.Summary{Name: "Total", BlockCoverage: 79.9, StmtCoverage: 82.3}}
+	results, err := evaluateThresholds(80, rep, "stmt")
+	assert.NoError(err)
+	assert.Equal([]thresholdResult{{Rule: "threshold", Name: "Total", Metric: "stmt", Op: ">=", Threshold: 80, Value: 82.3, Passed: true}}, results)
+
+	results, err = evaluateThresholds(80, rep, "block")
+	assert.NoError(err)
+	assert.False(results[0].Passed)
+	assert.Equal(79.9, results[0].Value)
+
+	results, err = evaluateThresholds(0, rep, "block")
+	assert.NoError(err)
+	assert.Empty(results, "No threshold")
+
+	_, err = evaluateThresholds(80, rep, "xxx")
+	assert.Error(err)
+}
+
+func TestParseCondition(t *testing.T) {
+	assert := assert.New(t)
+	c, err := parseCondition("missing-stmts<=500")
+	assert.NoError(err)
+	assert.Equal("missing-stmts", c.column.ID)
+	assert.Equal("<=", c.op)
+	assert.Equal(500.0, c.threshold)
+
+	for _, invalid := range []string{"stmt", "stmt => 80", "xxx >= 80", "name >= 1", "stmt >= 1.2.3"} {
+		_, err = parseCondition(invalid)
+		assert.Error(err, invalid)
+	}
+}
+
+func TestConditionOperators(t *testing.T) {
+	s := report.Summary{Name: "a.go", StmtCoverage: 80}
+	for op, expected := range map[string]bool{">=": true, "<=": true, ">": false, "<": false, "==": true, "!=": false} {
+		c, err := parseCondition("stmt " + op + " 80")
+		require.NoError(t, err)
+		assert.Equal(t, expected, c.check("rule", s).Passed, op)
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	assert := assert.New(t)
+	rep := report.Report{
+		Total: report.Summary{Name: "Total", Stmts: 100, MissingStmts: 20, StmtCoverage: 80, BlockCoverage: 70},
+		Files: []report.Summary{
+			{Name: "a.go", StmtCoverage: 95},
+			{Name: "b.go", StmtCoverage: 25}}}
+	rules := []policyRule{
+		{Name: "statements", Condition: "stmt >= 80"},
+		{Condition: "block >= 75"},
+		{Name: "no file below 30", Condition: "stmt >= 30", Scope: "files"},
+		{Name: "missing", Condition: "missing-stmts <= 500", Scope: "total"}}
+	results, err := evaluatePolicy(rules, rep)
+	assert.NoError(err)
+	assert.Len(results, 5)
+	assert.Equal(thresholdResult{Rule: "block >= 75", Name: "Total", Metric: "block", Op: ">=", Threshold: 75, Value: 70}, results[1])
+
+	buf := bytes.Buffer{}
+	assert.Equal(2, printPolicy(results, &buf))
+	assert.Equal("PASS statements\n"+
+		"FAIL block >= 75: Total block is 70.00\n"+
+		"FAIL no file below 30: b.go stmt is 25.00\n"+
+		"PASS missing\n", buf.String())
+
+	_, err = evaluatePolicy([]policyRule{{Condition: "xxx"}}, rep)
+	assert.Error(err)
+	_, err = evaluatePolicy([]policyRule{{Condition: "stmt > 1", Scope: "xxx"}}, rep)
+	assert.Error(err)
+}
+
+func TestRunPolicy(t *testing.T) {
+	assert := assert.New(t)
+	config := configuration{Policy: []policyRule{
+		{Name: "statements", Condition: "stmt >= 80"},
+		{Name: "files", Condition: "stmt >= 70", Scope: "files"}}}
+	args := arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc"}
+	buf := bytes.Buffer{}
+	results, err := run(config, args, &buf)
+	assert.False(results.passed())
+	var policyErr *policyFailure
+	assert.True(errors.As(err, &policyErr))
+	assert.Equal("1 policy rules failed", err.Error())
+	assert.Contains(buf.String(), "PASS statements\n")
+	assert.Contains(buf.String(), "FAIL files: github.com/mcubik/goverreport/main.go stmt is 65.91\n")
+
+	config.Policy = config.Policy[:1]
+	results, err = run(config, args, &buf)
+	assert.True(results.passed())
+	assert.NoError(err)
+
+	config.Policy = []policyRule{{Condition: "xxx"}}
+	_, err = run(config, args, &buf)
+	assert.Error(err)
+}
+
+func TestStatusWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.Equal(t, buf, statusWriter(arguments{format: "table"}, buf))
+	assert.NotEqual(t, buf, statusWriter(arguments{format: "json"}, buf))
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Tests that cover a file
+type FileTests struct {
+	Name  string   `json:"name"`
+	Tests []string `json:"tests"`
+}
+
+// Attribution maps the blocks of every file to the tests that cover them.
+// It is built from a set of per-test coverage profiles.
+type Attribution struct {
+	Tests []string                         // Names of all the ingested tests
+	files map[string]map[blockKey][]string // Covering tests by file and block
+}
+
+// Identifies a block independently of its execution count
+type blockKey struct {
+	startLine, startCol, endLine, endCol int
+}
+
+// Loads every coverage profile in a directory, one per test, and builds the
+// attribution index. The name of each test is the name of its profile without
+// the extension (TestX.out holds the coverage of TestX).
+// root, mappings and exclusions work as in GenerateReport.
+func LoadAttribution(dir, root string, mappings []PathMapping, exclusions []string) (*Attribution, error) {
+	mappers, err := compileMappings(mappings)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	a := &Attribution{files: make(map[string]map[blockKey][]string)}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		testName := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		profiles, err := ReadProfiles(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid coverprofile for test %s: '%s'", testName, err)
+		}
+		a.Tests = append(a.Tests, testName)
+		for _, profile := range profiles {
+			fileName := normalizeName(mapPath(profile.FileName, mappers), root, false)
+			if isExcluded(fileName, exclusions) {
+				continue
+			}
+			a.add(fileName, testName, profile.Blocks)
+		}
+	}
+	sort.Strings(a.Tests)
+	return a, nil
+}
+
+// Records the blocks of a file executed by a test
+func (a *Attribution) add(fileName, testName string, blocks []cover.ProfileBlock) {
+	fileBlocks, ok := a.files[fileName]
+	if !ok {
+		fileBlocks = make(map[blockKey][]string)
+		a.files[fileName] = fileBlocks
+	}
+	for _, block := range blocks {
+		key := blockKey{block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+		tests := fileBlocks[key]
+		if block.Count > 0 && !contains(tests, testName) {
+			tests = append(tests, testName)
+		}
+		fileBlocks[key] = tests
+	}
+}
+
+// Returns the tests that execute a given line of a file, sorted by name
+func (a *Attribution) TestsCovering(fileName string, line int) ([]string, error) {
+	blocks, err := a.lookup(fileName)
+	if err != nil {
+		return nil, err
+	}
+	tests := []string{}
+	for key, blockTests := range blocks {
+		if line < key.startLine || line > key.endLine {
+			continue
+		}
+		for _, test := range blockTests {
+			if !contains(tests, test) {
+				tests = append(tests, test)
+			}
+		}
+	}
+	sort.Strings(tests)
+	return tests, nil
+}
+
+// Returns the tests that execute any block of a file, sorted by name
+func (a *Attribution) FileTests(fileName string) ([]string, error) {
+	blocks, err := a.lookup(fileName)
+	if err != nil {
+		return nil, err
+	}
+	tests := []string{}
+	for _, blockTests := range blocks {
+		for _, test := range blockTests {
+			if !contains(tests, test) {
+				tests = append(tests, test)
+			}
+		}
+	}
+	sort.Strings(tests)
+	return tests, nil
+}
+
+// Finds the blocks of a file by its exact name or, failing that, by a path
+// suffix, so that "report/report.go" matches "/report/report.go". A suffix
+// shared by several files is ambiguous.
+func (a *Attribution) lookup(fileName string) (map[blockKey][]string, error) {
+	if blocks, ok := a.files[fileName]; ok {
+		return blocks, nil
+	}
+	var matches []string
+	for name := range a.files {
+		if strings.HasSuffix(name, "/"+fileName) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return a.files[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return nil, fmt.Errorf("Ambiguous location '%s', it matches %s", fileName, strings.Join(matches, ", "))
+	}
+}
+
+// Returns the files that are covered by a single test, sorted by name
+func (a *Attribution) SingleTestFiles() []FileTests {
+	result := []FileTests{}
+	for fileName := range a.files {
+		// Exact names are never ambiguous
+		if tests, _ := a.FileTests(fileName); len(tests) == 1 {
+			result = append(result, FileTests{Name: fileName, Tests: tests})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Parses a "file:line" location
+func ParseLocation(location string) (string, int, error) {
+	sep := strings.LastIndex(location, ":")
+	if sep < 0 {
+		return "", 0, fmt.Errorf("Invalid location '%s', use file:line", location)
+	}
+	var line int
+	if _, err := fmt.Sscanf(location[sep+1:], "%d", &line); err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("Invalid line number in location '%s'", location)
+	}
+	return location[:sep], line, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAttribution(t *testing.T) {
+	assert := assert.New(t)
+	a, err := LoadAttribution("testdata/tests", "github.com/mcubik/goverreport", nil, []string{})
+	require.NoError(t, err)
+	assert.Equal([]string{"TestReport", "TestSortByFileName"}, a.Tests)
+	covering := func(fileName string, line int) []string {
+		tests, err := a.TestsCovering(fileName, line)
+		require.NoError(t, err)
+		return tests
+	}
+	assert.Equal([]string{"TestReport", "TestSortByFileName"}, covering("/report/report.go", 61))
+	assert.Equal([]string{"TestReport"}, covering("report/report.go", 71))
+	assert.Equal([]string{}, covering("report/report.go", 65))
+	assert.Equal([]string{}, covering("xxx.go", 1))
+}
+
+func TestAmbiguousLocation(t *testing.T) {
+	a := &Attribution{files: map[string]map[blockKey][]string{"/b/report.go": {}, "/a/report.go": {}, "/a/view.go": {}}}
+	_, err := a.TestsCovering("report.go", 1)
+	assert.EqualError(t, err, "Ambiguous location 'report.go', it matches /a/report.go, /b/report.go")
+	_, err = a.FileTests("report.go")
+	assert.Error(t, err)
+	tests, err := a.TestsCovering("view.go", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, tests)
+}
+
+func TestSingleTestFiles(t *testing.T) {
+	a, err := LoadAttribution("testdata/tests", "github.com/mcubik/goverreport", nil, []string{})
+	require.NoError(t, err)
+	assert.Equal(t, []FileTests{{Name: "/report/view.go", Tests: []string{"TestSortByFileName"}}}, a.SingleTestFiles())
+}
+
+func TestAttributionExclusions(t *testing.T) {
+	a, err := LoadAttribution("testdata/tests", "github.com/mcubik/goverreport", nil, []string{"/report/view"})
+	require.NoError(t, err)
+	assert.Empty(t, a.SingleTestFiles())
+}
+
+func TestInvalidAttributionDir(t *testing.T) {
+	_, err := LoadAttribution("testdata/xxx", "", nil, []string{})
+	assert.Error(t, err)
+	_, err = LoadAttribution("testdata/badtests", "", nil, []string{})
+	assert.Error(t, err, "Malformed profile")
+}
+
+func TestParseLocation(t *testing.T) {
+	assert := assert.New(t)
+	file, line, err := ParseLocation("report/report.go:60")
+	assert.NoError(err)
+	assert.Equal("report/report.go", file)
+	assert.Equal(60, line)
+
+	_, _, err = ParseLocation("report/report.go")
+	assert.Error(err)
+	_, _, err = ParseLocation("report/report.go:x")
+	assert.Error(err)
+	_, _, err = ParseLocation("report/report.go:0")
+	assert.Error(err)
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"golang.org/x/tools/cover"
+)
+
+// Cobertura XML report, with the lines of each class
+type coberturaReport struct {
+	Classes []struct {
+		Filename string `xml:"filename,attr"`
+		Lines    []struct {
+			Number int `xml:"number,attr"`
+			Hits   int `xml:"hits,attr"`
+		} `xml:"lines>line"`
+	} `xml:"packages>package>classes>class"`
+}
+
+// Whether a coverage file is a Cobertura report: an XML document whose
+// root element is coverage
+func isCobertura(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) > 1024 {
+		data = data[:1024]
+	}
+	return bytes.HasPrefix(data, []byte("<")) && bytes.Contains(data, []byte("<coverage"))
+}
+
+// Parses a Cobertura XML report into coverage profiles (see lineProfiles).
+// Files are named by the filename of their classes, and the classes of the
+// same file are merged.
+func parseCobertura(r io.Reader) ([]*cover.Profile, error) {
+	var report coberturaReport
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura report: %s", err)
+	}
+	files := make(map[string]map[int]int)
+	for _, class := range report.Classes {
+		if class.Filename == "" {
+			return nil, fmt.Errorf("invalid Cobertura report: class without a filename")
+		}
+		lines, ok := files[class.Filename]
+		if !ok {
+			lines = make(map[int]int)
+			files[class.Filename] = lines
+		}
+		for _, line := range class.Lines {
+			if line.Number < 1 || line.Hits < 0 {
+				return nil, fmt.Errorf("invalid Cobertura report: line %d of %s with %d hits", line.Number, class.Filename, line.Hits)
+			}
+			lines[line.Number] += line.Hits
+		}
+	}
+	return lineProfiles(files), nil
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func TestParseCobertura(t *testing.T) {
+	assert := assert.New(t)
+	profiles, err := ReadProfiles("testdata/cobertura.xml")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal("example.com/mod/a.go", profiles[0].FileName)
+	assert.Equal([]cover.ProfileBlock{
+		{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 1, NumStmt: 1, Count: 1},
+		{StartLine: 5, StartCol: 1, EndLine: 5, EndCol: 1, NumStmt: 1, Count: 2},
+		{StartLine: 9, StartCol: 1, EndLine: 9, EndCol: 1, NumStmt: 1, Count: 0}}, profiles[0].Blocks,
+		"The classes of a file are merged, ignoring the lines of their methods")
+	assert.Equal("example.com/mod/sub/b.go", profiles[1].FileName)
+
+	for _, invalid := range []string{
+		"<coverage><packages>",
+		`<coverage><packages><package><classes><class><lines><line number="1" hits="1"/></lines></class></classes></package></packages></coverage>`,
+		`<coverage><packages><package><classes><class filename="a.go"><lines><line number="0" hits="1"/></lines></class></classes></package></packages></coverage>`,
+	} {
+		_, err = parseCobertura(strings.NewReader(invalid))
+		assert.Error(err, invalid)
+	}
+}
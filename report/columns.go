@@ -0,0 +1,141 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Column of a tabular report
+type Column struct {
+	ID      string // Identifier used to select the column
+	Header  string // Title of the column in the table
+	Field   string // Name of the field in machine readable outputs
+	Numeric bool   // Whether the column holds a number
+
+	value func(s Summary) float64 // Numeric value of the column
+	round bool                    // Whether the value is a percentage shown with two decimals
+}
+
+// Format returns the value of the column for a summary as shown in the table
+func (c Column) Format(s Summary) string {
+	if !c.Numeric {
+		return s.Name
+	}
+	if c.round {
+		return fmt.Sprintf("%.2f", c.value(s))
+	}
+	return fmt.Sprintf("%.0f", c.value(s))
+}
+
+// Value returns the numeric value of the column for a summary
+func (c Column) Value(s Summary) float64 {
+	if !c.Numeric {
+		return 0
+	}
+	return c.value(s)
+}
+
+// Raw returns the value of the column for a summary without rounding
+func (c Column) Raw(s Summary) string {
+	if !c.Numeric {
+		return s.Name
+	}
+	return strconv.FormatFloat(c.value(s), 'f', -1, 64)
+}
+
+// Available columns. The name column is titled File or Package depending
+// on the report. Machine readable outputs name the columns like the JSON
+// fields of a Summary.
+var columnRegistry = []Column{
+	{ID: "name", Header: "File", Field: "file"},
+	{ID: "blocks", Header: "Blocks", Field: "blocks", Numeric: true, value: func(s Summary) float64 { return float64(s.Blocks) }},
+	{ID: "missing-blocks", Header: "Missing", Field: "missingBlocks", Numeric: true, value: func(s Summary) float64 { return float64(s.MissingBlocks) }},
+	{ID: "covered-blocks", Header: "Covered", Field: "coveredBlocks", Numeric: true, value: func(s Summary) float64 { return float64(s.Blocks - s.MissingBlocks) }},
+	{ID: "stmts", Header: "Stmts", Field: "stmts", Numeric: true, value: func(s Summary) float64 { return float64(s.Stmts) }},
+	{ID: "missing-stmts", Header: "Missing", Field: "missingStmts", Numeric: true, value: func(s Summary) float64 { return float64(s.MissingStmts) }},
+	{ID: "covered-stmts", Header: "Covered", Field: "coveredStmts", Numeric: true, value: func(s Summary) float64 { return float64(s.Stmts - s.MissingStmts) }},
+	{ID: "lines", Header: "Lines", Field: "lines", Numeric: true, value: func(s Summary) float64 { return float64(s.Lines) }},
+	{ID: "missing-lines", Header: "Missing", Field: "missingLines", Numeric: true, value: func(s Summary) float64 { return float64(s.MissingLines) }},
+	{ID: "covered-lines", Header: "Covered", Field: "coveredLines", Numeric: true, value: func(s Summary) float64 { return float64(s.Lines - s.MissingLines) }},
+	{ID: "functions", Header: "Funcs", Field: "functions", Numeric: true, value: func(s Summary) float64 { return float64(s.Functions) }},
+	{ID: "missing-functions", Header: "Missing", Field: "missingFunctions", Numeric: true, value: func(s Summary) float64 { return float64(s.MissingFunctions) }},
+	{ID: "covered-functions", Header: "Covered", Field: "coveredFunctions", Numeric: true, value: func(s Summary) float64 { return float64(s.Functions - s.MissingFunctions) }},
+	{ID: "block", Header: "Block cover %", Field: "blockCoverage", Numeric: true, round: true, value: func(s Summary) float64 { return s.BlockCoverage }},
+	{ID: "stmt", Header: "Stmt cover %", Field: "stmtCoverage", Numeric: true, round: true, value: func(s Summary) float64 { return s.StmtCoverage }},
+	{ID: "line", Header: "Line cover %", Field: "lineCoverage", Numeric: true, round: true, value: func(s Summary) float64 { return s.LineCoverage }},
+	{ID: "function", Header: "Func cover %", Field: "functionCoverage", Numeric: true, round: true, value: func(s Summary) float64 { return s.FunctionCoverage }},
+}
+
+// Columns shown when none are configured
+var DefaultColumns = []string{"name", "blocks", "missing-blocks", "stmts", "missing-stmts", "block", "stmt"}
+
+// Returns the identifiers of all the available columns
+func ColumnIDs() []string {
+	ids := make([]string, 0, len(columnRegistry))
+	for _, c := range columnRegistry {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// Looks up a list of columns by their identifiers, keeping their order.
+// If the list is empty, returns the default columns.
+func ParseColumns(ids []string) ([]Column, error) {
+	if len(ids) == 0 {
+		ids = DefaultColumns
+	}
+	columns := make([]Column, 0, len(ids))
+	for _, id := range ids {
+		column, ok := findColumn(strings.TrimSpace(id))
+		if !ok {
+			return nil, fmt.Errorf("Invalid column '%s', must be one of %s", id, strings.Join(ColumnIDs(), ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func findColumn(id string) (Column, bool) {
+	for _, c := range columnRegistry {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Headers of a set of columns
+func headers(columns []Column, packages bool) []string {
+	result := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !c.Numeric && packages {
+			result = append(result, "Package")
+		} else {
+			result = append(result, c.Header)
+		}
+	}
+	return result
+}
+
+// Machine readable names of a set of columns
+func fields(columns []Column, packages bool) []string {
+	result := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !c.Numeric && packages {
+			result = append(result, "package")
+		} else {
+			result = append(result, c.Field)
+		}
+	}
+	return result
+}
+
+// Formats a summary as a table row with the given columns
+func formatRow(s Summary, columns []Column) []string {
+	row := make([]string, 0, len(columns))
+	for _, c := range columns {
+		row = append(row, c.Format(s))
+	}
+	return row
+}
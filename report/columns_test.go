@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumns(t *testing.T) {
+	assert := assert.New(t)
+	columns, err := ParseColumns(nil)
+	assert.NoError(err)
+	assert.Len(columns, 7)
+
+	columns, err = ParseColumns([]string{"stmt", " name", "covered-stmts"})
+	assert.NoError(err)
+	assert.Equal([]string{"Stmt cover %", "Package", "Covered"}, headers(columns, true))
+	assert.Equal([]string{"stmtCoverage", "file", "coveredStmts"}, fields(columns, false))
+
+	_, err = ParseColumns([]string{"name", "xxx"})
+	assert.Error(err)
+}
+
+func TestColumnFormat(t *testing.T) {
+	s := Summary{Name: "a.go", Stmts: 3, MissingStmts: 1, StmtCoverage: 200.0 / 3}
+	columns, err := ParseColumns([]string{"name", "covered-stmts", "stmt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "2", "66.67"}, formatRow(s, columns))
+	assert.Equal(t, "66.66666666666667", columns[2].Raw(s))
+	assert.Equal(t, "a.go", columns[0].Raw(s))
+
+	s = Summary{Name: "a.go", Lines: 8, MissingLines: 2, LineCoverage: 75}
+	columns, err = ParseColumns([]string{"lines", "missing-lines", "covered-lines", "line"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"8", "2", "6", "75.00"}, formatRow(s, columns))
+	assert.Equal(t, []string{"lines", "missingLines", "coveredLines", "lineCoverage"}, fields(columns, false))
+
+	s = Summary{Name: "a.go", Functions: 4, MissingFunctions: 1, FunctionCoverage: 75}
+	columns, err = ParseColumns([]string{"functions", "missing-functions", "covered-functions", "function"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "1", "3", "75.00"}, formatRow(s, columns))
+	assert.Equal(t, []string{"Funcs", "Missing", "Covered", "Func cover %"}, headers(columns, false))
+}
+
+func TestPrintTableWithColumns(t *testing.T) {
+	columns, err := ParseColumns([]string{"name", "stmt", "missing-stmts"})
+	require.NoError(t, err)
+	report := Report{
+		Files: []Summary{{Name: "/main.go", Blocks: 30, Stmts: 44, MissingStmts: 15, StmtCoverage: 65.91}},
+		Total: Summary{Name: "Total", Blocks: 30, Stmts: 44, MissingStmts: 15, StmtCoverage: 65.91}}
+	var buf bytes.Buffer
+	require.NoError(t, PrintTable(report, &buf, false, WithColumns(columns)))
+	assert.Contains(t, buf.String(), "|   File   | Stmt cover % | Missing |")
+	assert.Contains(t, buf.String(), "| /main.go | 65.91        | 15      |")
+	assert.NotContains(t, buf.String(), "Blocks")
+
+	buf.Reset()
+	require.NoError(t, PrintHTML(report, &buf, false, WithColumns(columns)))
+	assert.Contains(t, buf.String(), `<tr><th>File</th><th class="num">Stmt cover %</th><th class="num">Missing</th></tr>`)
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// Options of the CSV output
+type CSVOptions struct {
+	Comma    rune     // Field separator, ',' for CSV and '\t' for TSV
+	Header   bool     // Whether to print a header row
+	Total    bool     // Whether to print the total as the last row
+	Packages bool     // Whether the report is by package instead of by file
+	Columns  []Column // Columns to print, the default ones if empty
+	Groups   []Group  // Groups of files, each followed by a subtotal row, if the files are grouped
+}
+
+// PrintCSV prints the report as comma (or tab) separated values. Unlike
+// the table, the coverage values are printed with full precision.
+func PrintCSV(r Report, w io.Writer, opts CSVOptions) error {
+	writer := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns, _ = ParseColumns(DefaultColumns)
+	}
+	if opts.Header {
+		if err := writer.Write(fields(columns, opts.Packages)); err != nil {
+			return err
+		}
+	}
+	for _, row := range (tableOptions{groups: opts.Groups}).rows(r) {
+		if err := writer.Write(makeRecord(row.Summary, columns)); err != nil {
+			return err
+		}
+	}
+	if opts.Total {
+		if err := writer.Write(makeRecord(r.Total, columns)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Converts a Summary to a CSV record without rounding
+func makeRecord(c Summary, columns []Column) []string {
+	record := make([]string, 0, len(columns))
+	for _, column := range columns {
+		record = append(record, column.Raw(c))
+	}
+	return record
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var csvReport = Report{
+	Files: []Summary{{Name: "main.go", Blocks: 3, MissingBlocks: 1, Stmts: 3, MissingStmts: 1, BlockCoverage: 200.0 / 3, StmtCoverage: 200.0 / 3}},
+	Total: Summary{Name: "Total", Blocks: 3, MissingBlocks: 1, Stmts: 3, MissingStmts: 1, BlockCoverage: 200.0 / 3, StmtCoverage: 200.0 / 3},
+}
+
+func TestPrintCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PrintCSV(csvReport, &buf, CSVOptions{Header: true, Total: true}))
+	assert.Equal(t, "file,blocks,missingBlocks,stmts,missingStmts,blockCoverage,stmtCoverage\n"+
+		"main.go,3,1,3,1,66.66666666666667,66.66666666666667\n"+
+		"Total,3,1,3,1,66.66666666666667,66.66666666666667\n", buf.String())
+}
+
+func TestPrintTSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PrintCSV(csvReport, &buf, CSVOptions{Comma: '\t', Packages: true}))
+	assert.Equal(t, "main.go\t3\t1\t3\t1\t66.66666666666667\t66.66666666666667\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, PrintCSV(csvReport, &buf, CSVOptions{Comma: '\t', Header: true, Packages: true}))
+	assert.Contains(t, buf.String(), "package\tblocks\t")
+}
+
+func TestPrintCSVGrouped(t *testing.T) {
+	r := Top(Report{
+		Total: Summary{Name: "Total", Stmts: 10, MissingStmts: 4},
+		Files: []Summary{
+			{Name: "/a/x.go", Stmts: 4, MissingStmts: 2},
+			{Name: "/a/y.go", Stmts: 4, MissingStmts: 0},
+			{Name: "/b/z.go", Stmts: 2, MissingStmts: 2}}}, 2, 0)
+	grouped, err := GroupByPackage(r, "filename", "asc")
+	require.NoError(t, err)
+	columns, err := ParseColumns([]string{"name", "stmts", "missing-stmts"})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, PrintCSV(r, &buf, CSVOptions{Header: true, Columns: columns, Groups: grouped.Groups}))
+	assert.Equal(t, "file,stmts,missingStmts\n"+
+		"/a/x.go,4,2\n"+
+		"/a/y.go,4,0\n"+
+		"Subtotal /a,8,2\n"+
+		"Others (1),2,2\n", buf.String())
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Position of a function in a source file, as line and column pairs
+type function struct {
+	startLine, startCol, endLine, endCol int
+}
+
+// Adds the functions of the source files to a report, the way
+// "go tool cover -func" finds them: a function is covered if any of the
+// blocks inside it ran. files are the blocks of the profile as loaded by
+// LoadFileBlocks; files whose source can't be found count no functions.
+// root and packages work as in GenerateReport.
+func AddFunctions(r Report, files map[string]*FileBlocks, root string, packages bool) (Report, error) {
+	counts := make(map[string]*accumulator)
+	total := &accumulator{}
+	for _, f := range files {
+		if f.Path == "" || !strings.HasSuffix(f.Path, ".go") {
+			continue
+		}
+		functions, err := sourceFunctions(f.Path)
+		if err != nil {
+			return r, err
+		}
+		name := normalizeName(f.Profile, root, packages)
+		acc, ok := counts[name]
+		if !ok {
+			acc = &accumulator{}
+			counts[name] = acc
+		}
+		for _, fn := range functions {
+			covered := fn.covered(f.Blocks)
+			for _, a := range []*accumulator{acc, total} {
+				a.functions++
+				if covered {
+					a.coveredFunctions++
+				}
+			}
+		}
+	}
+	result := Report{Total: r.Total.withFunctions(total), Files: make([]Summary, len(r.Files))}
+	for i, s := range r.Files {
+		if acc, ok := counts[s.Name]; ok {
+			s = s.withFunctions(acc)
+		}
+		result.Files[i] = s
+	}
+	return result, nil
+}
+
+// Copy of the summary with the functions of an accumulator
+func (s Summary) withFunctions(a *accumulator) Summary {
+	s.Functions = a.functions
+	s.MissingFunctions = a.functions - a.coveredFunctions
+	s.FunctionCoverage = percentage(a.coveredFunctions, a.functions)
+	return s
+}
+
+// Whether any block starting inside the function ran
+func (fn function) covered(blocks []cover.ProfileBlock) bool {
+	for _, b := range blocks {
+		if b.Count == 0 || b.StartLine < fn.startLine || b.StartLine > fn.endLine {
+			continue
+		}
+		if b.StartLine == fn.startLine && b.StartCol < fn.startCol || b.StartLine == fn.endLine && b.StartCol > fn.endCol {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Finds the functions and methods with a body in a source file
+func sourceFunctions(file string) ([]function, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	return declaredFunctions(fset, f), nil
+}
+
+// Functions and methods with a body of a parsed file
+func declaredFunctions(fset *token.FileSet, f *ast.File) []function {
+	var functions []function
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		functions = append(functions, function{start.Line, start.Column, end.Line, end.Column})
+	}
+	return functions
+}
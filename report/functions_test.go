@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func TestAddFunctions(t *testing.T) {
+	assert := assert.New(t)
+	files, err := LoadFileBlocks("testdata/module.out", "testdata/module", "example.com/mod", nil, []string{})
+	require.NoError(t, err)
+	rep, err := AddFunctions(moduleReport(t, false), files, "example.com/mod", false)
+	require.NoError(t, err)
+	assert.Equal(1, rep.Files[0].Functions)
+	assert.Equal(0, rep.Files[0].MissingFunctions)
+	assert.Equal(100.0, rep.Files[0].FunctionCoverage)
+	assert.Equal(1, rep.Total.Functions)
+	assert.Equal(1, rep.Total.Stmts, "The rest of the summary is kept")
+
+	rep, err = AddFunctions(moduleReport(t, true), files, "example.com/mod", true)
+	require.NoError(t, err)
+	assert.Equal(".", rep.Files[0].Name)
+	assert.Equal(1, rep.Files[0].Functions)
+
+	rep, err = AddFunctions(moduleReport(t, false), map[string]*FileBlocks{"/a.go": {Name: "/a.go"}}, "example.com/mod", false)
+	require.NoError(t, err)
+	assert.Equal(0, rep.Total.Functions, "Files without sources count no functions")
+
+	_, err = AddFunctions(moduleReport(t, false), map[string]*FileBlocks{"/x.go": {Path: "testdata/badtests/TestBroken.out/x.go"}}, "", false)
+	assert.Error(err)
+}
+
+func TestFunctionCovered(t *testing.T) {
+	fn := function{startLine: 3, startCol: 1, endLine: 5, endCol: 2}
+	block := func(line, col, count int) []cover.ProfileBlock {
+		return []cover.ProfileBlock{{StartLine: line, StartCol: col, EndLine: line, EndCol: col + 1, Count: count}}
+	}
+	assert.True(t, fn.covered(block(3, 14, 1)))
+	assert.True(t, fn.covered(block(5, 1, 1)))
+	assert.False(t, fn.covered(block(3, 14, 0)), "Blocks that didn't run")
+	assert.False(t, fn.covered(block(2, 14, 1)), "Blocks before the function")
+	assert.False(t, fn.covered(block(5, 3, 1)), "Blocks after the function")
+	assert.False(t, fn.covered(block(6, 1, 1)))
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Files of a package, with their subtotal
+type Group struct {
+	Summary           // Subtotal of the files, named after the package
+	Files   []Summary `json:"files"`
+}
+
+// Report with the files grouped by package
+type GroupedReport struct {
+	Total  Summary `json:"total"`
+	Groups []Group `json:"packages"`
+}
+
+// Groups the files of a report by package, adding up their blocks and
+// statements to get the subtotal of each package. The files keep their order
+// within each group, and the groups are sorted by their subtotals with the
+// same keys (see sortResults). The rows aggregated by Top are left out,
+// since their files may come from several packages.
+func GroupByPackage(r Report, sortBy, order string) (GroupedReport, error) {
+	less, err := sortOrder(sortBy, order)
+	if err != nil {
+		return GroupedReport{}, err
+	}
+	index := make(map[string]int)
+	var groups []Group
+	for _, s := range r.Files {
+		if s.others {
+			continue
+		}
+		pkg := filepath.Dir(s.Name)
+		i, ok := index[pkg]
+		if !ok {
+			i = len(groups)
+			index[pkg] = i
+			groups = append(groups, Group{Summary: Summary{Name: pkg}})
+		}
+		groups[i].Files = append(groups[i].Files, s)
+	}
+	for i := range groups {
+		groups[i].Summary = Aggregate(groups[i].Name, groups[i].Files)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return less(groups[i].Summary, groups[j].Summary)
+	})
+	return GroupedReport{Total: r.Total, Groups: groups}, nil
+}
+
+// Rows of a report grouped by package: the files of every group followed by
+// the subtotal of the group, and then the rows that belong to no package
+func groupedRows(r Report, groups []Group) []tableRow {
+	var rows []tableRow
+	for _, g := range groups {
+		for _, s := range g.Files {
+			rows = append(rows, tableRow{s, false})
+		}
+		subtotal := g.Summary
+		subtotal.Name = "Subtotal " + subtotal.Name
+		rows = append(rows, tableRow{subtotal, true})
+	}
+	for _, s := range r.Files {
+		if s.others {
+			rows = append(rows, tableRow{s, false})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByPackage(t *testing.T) {
+	assert := assert.New(t)
+	r := Report{
+		Total: Summary{Name: "Total", Blocks: 40, MissingBlocks: 13, Stmts: 80, MissingStmts: 25},
+		Files: []Summary{
+			{Name: "/b/x.go", Blocks: 10, MissingBlocks: 2, Stmts: 20, MissingStmts: 5},
+			{Name: "/a/y.go", Blocks: 10, MissingBlocks: 10, Stmts: 20, MissingStmts: 20},
+			{Name: "/b/z.go", Blocks: 20, MissingBlocks: 1, Stmts: 40, MissingStmts: 0},
+		}}
+	grouped, err := GroupByPackage(r, "stmt", "desc")
+	require.NoError(t, err)
+	assert.Equal(r.Total, grouped.Total)
+	require.Len(t, grouped.Groups, 2)
+	b, a := grouped.Groups[0], grouped.Groups[1]
+	assert.Equal(Aggregate("/b", []Summary{r.Files[0], r.Files[2]}), b.Summary)
+	assert.Equal(90.0, b.BlockCoverage)
+	assert.Equal([]Summary{r.Files[0], r.Files[2]}, b.Files, "Files keep their order")
+	assert.Equal("/a", a.Name)
+	assert.Equal(0.0, a.StmtCoverage)
+
+	grouped, err = GroupByPackage(Top(r, 1, 0), "stmt", "desc")
+	require.NoError(t, err)
+	require.Len(t, grouped.Groups, 1, "Others isn't grouped under package '.'")
+	assert.Equal("/b", grouped.Groups[0].Name)
+
+	_, err = GroupByPackage(r, "xxx", "asc")
+	assert.Error(err)
+}
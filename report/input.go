@@ -0,0 +1,73 @@
+package report
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// Format of a coverage file, read into coverage profiles
+type inputFormat struct {
+	name   string
+	detect func(data []byte) bool // Whether the content of a file is in this format
+	parse  func(io.Reader) ([]*cover.Profile, error)
+}
+
+// Supported input formats, in the order they are detected. Files that
+// don't match any of them are read as Go coverprofiles.
+var inputFormats = []inputFormat{
+	{"go", isGoProfile, cover.ParseProfilesFromReader},
+	{"lcov", isLCOV, parseLCOV},
+	{"cobertura", isCobertura, parseCobertura},
+}
+
+// Reads a coverage file in any of the input formats (Go coverprofile, LCOV
+// or Cobertura), detected by its content
+func ReadProfiles(filename string) ([]*cover.Profile, error) {
+	// #nosec G304 -- reads the coverage file given by the user
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return detectFormat(data).parse(bytes.NewReader(data))
+}
+
+// Finds the format of the content of a coverage file
+func detectFormat(data []byte) inputFormat {
+	for _, format := range inputFormats {
+		if format.detect(data) {
+			return format
+		}
+	}
+	return inputFormats[0]
+}
+
+// Whether a coverage file is a Go coverprofile, which starts with its mode
+func isGoProfile(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("mode:"))
+}
+
+// Builds coverage profiles from the execution counts of the lines of each
+// file, for formats that report lines rather than blocks. Each line becomes
+// a block of a single statement.
+func lineProfiles(files map[string]map[int]int) []*cover.Profile {
+	profiles := make([]*cover.Profile, 0, len(files))
+	for name, lines := range files {
+		profile := &cover.Profile{FileName: name, Mode: "count"}
+		for number, count := range lines {
+			profile.Blocks = append(profile.Blocks, cover.ProfileBlock{
+				StartLine: number, StartCol: 1, EndLine: number, EndCol: 1, NumStmt: 1, Count: count})
+		}
+		sort.Slice(profile.Blocks, func(i, j int) bool {
+			return profile.Blocks[i].StartLine < profile.Blocks[j].StartLine
+		})
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].FileName < profiles[j].FileName
+	})
+	return profiles
+}
@@ -0,0 +1,28 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("go", detectFormat([]byte("mode: set\na.go:1.1,2.2 1 1\n")).name)
+	assert.Equal("lcov", detectFormat([]byte("TN:\nSF:a.go\n")).name)
+	assert.Equal("lcov", detectFormat([]byte("\nSF:a.go\n")).name)
+	assert.Equal("cobertura", detectFormat([]byte(`<?xml version="1.0"?>`+"\n<coverage>")).name)
+	assert.Equal("go", detectFormat([]byte("<html></html>")).name, "Unknown formats are read as coverprofiles")
+	assert.Equal("go", detectFormat([]byte("")).name)
+}
+
+func TestGenerateReportFromEveryFormat(t *testing.T) {
+	for _, file := range []string{"testdata/module.out", "testdata/coverage.dat", "testdata/cobertura.xml"} {
+		rep, err := GenerateReport(file, "example.com/mod", nil, []string{"/sub"}, "filename", "asc", false)
+		require.NoError(t, err, file)
+		assert.Equal(t, "/a.go", rep.Files[0].Name, file)
+	}
+	_, err := GenerateReport("testdata/xxx.xml", "", nil, []string{}, "filename", "asc", false)
+	assert.Error(t, err)
+}
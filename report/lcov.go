@@ -0,0 +1,60 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Whether a coverage file is an LCOV report, which starts with a test name
+// or a source file record
+func isLCOV(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return bytes.HasPrefix(data, []byte("TN:")) || bytes.HasPrefix(data, []byte("SF:"))
+}
+
+// Parses an LCOV report, such as the coverage.dat files written by Bazel's
+// rules_go, into coverage profiles (see lineProfiles). The records of a file
+// in several tests, as in Bazel's combined report, are merged by adding up
+// their counts.
+func parseLCOV(r io.Reader) ([]*cover.Profile, error) {
+	files := make(map[string]map[int]int)
+	var lines map[int]int
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			name := strings.TrimPrefix(line, "SF:")
+			if files[name] == nil {
+				files[name] = make(map[int]int)
+			}
+			lines = files[name]
+		case line == "end_of_record":
+			lines = nil
+		case strings.HasPrefix(line, "DA:"):
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if lines == nil || len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: invalid LCOV record '%s'", n, line)
+			}
+			number, err := strconv.Atoi(fields[0])
+			if err != nil || number < 1 {
+				return nil, fmt.Errorf("line %d: invalid line number in '%s'", n, line)
+			}
+			count, err := strconv.Atoi(fields[1])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("line %d: invalid execution count in '%s'", n, line)
+			}
+			lines[number] += count
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lineProfiles(files), nil
+}
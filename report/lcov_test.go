@@ -0,0 +1,39 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func TestParseLCOV(t *testing.T) {
+	assert := assert.New(t)
+	profiles, err := ReadProfiles("testdata/coverage.dat")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal("example.com/mod/a.go", profiles[0].FileName)
+	assert.Equal([]cover.ProfileBlock{
+		{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 1, NumStmt: 1, Count: 3},
+		{StartLine: 5, StartCol: 1, EndLine: 5, EndCol: 1, NumStmt: 1, Count: 3}}, profiles[0].Blocks,
+		"The records of a file are merged")
+	assert.Len(profiles[1].Blocks, 2)
+
+	for _, invalid := range []string{"DA:1,1\n", "SF:a.go\nDA:1\n", "SF:a.go\nDA:x,1\n", "SF:a.go\nDA:1,-1\n"} {
+		_, err = parseLCOV(strings.NewReader(invalid))
+		assert.Error(err, invalid)
+	}
+}
+
+func TestGenerateReportFromLCOV(t *testing.T) {
+	assert := assert.New(t)
+	rep, err := GenerateReport("testdata/coverage.dat", "example.com/mod", nil, []string{}, "filename", "asc", true)
+	require.NoError(t, err)
+	assert.Equal(Summary{Name: "Total", Blocks: 4, Stmts: 4, MissingBlocks: 2, MissingStmts: 2, BlockCoverage: 50, StmtCoverage: 50, Lines: 4, MissingLines: 2, LineCoverage: 50, FunctionCoverage: 100}, rep.Total)
+	assert.Equal([]string{".", "./sub"}, []string{rep.Files[0].Name, rep.Files[1].Name})
+
+	_, err = GenerateReport("testdata/xxx.dat", "", nil, []string{}, "filename", "asc", false)
+	assert.Error(err)
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default prefix of the OpenMetrics metric names
+const DefaultMetricsPrefix = "go_coverage"
+
+// Options of the OpenMetrics output
+type MetricsOptions struct {
+	Prefix   string            // Prefix of the metric names, DefaultMetricsPrefix if empty
+	Labels   map[string]string // Constant labels added to every sample
+	Packages bool              // Whether the report is by package instead of by file
+}
+
+// A metric family and how to get its value from a summary
+type metric struct {
+	name, help string
+	value      func(s Summary) float64
+}
+
+var metrics = []metric{
+	{"block_ratio", "Ratio of covered blocks.", func(s Summary) float64 { return s.BlockCoverage / 100 }},
+	{"statement_ratio", "Ratio of covered statements.", func(s Summary) float64 { return s.StmtCoverage / 100 }},
+	{"blocks", "Number of blocks.", func(s Summary) float64 { return float64(s.Blocks) }},
+	{"statements", "Number of statements.", func(s Summary) float64 { return float64(s.Stmts) }},
+	{"missing_blocks", "Number of blocks not covered.", func(s Summary) float64 { return float64(s.MissingBlocks) }},
+	{"missing_statements", "Number of statements not covered.", func(s Summary) float64 { return float64(s.MissingStmts) }},
+}
+
+var validMetricName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PrintOpenMetrics prints the report as OpenMetrics gauges, suitable for
+// the node_exporter textfile collector. Every file (or package) sample is
+// labeled with its package and file; the total has no such labels.
+func PrintOpenMetrics(r Report, w io.Writer, opts MetricsOptions) error {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = DefaultMetricsPrefix
+	}
+	if !validMetricName.MatchString(prefix) {
+		return fmt.Errorf("Invalid metrics prefix '%s'", prefix)
+	}
+	constLabels := make([]string, 0, len(opts.Labels))
+	for name := range opts.Labels {
+		if !validMetricName.MatchString(name) || name == "package" || name == "file" {
+			return fmt.Errorf("Invalid metrics label '%s'", name)
+		}
+		constLabels = append(constLabels, name)
+	}
+	sort.Strings(constLabels)
+
+	var b strings.Builder
+	for _, m := range metrics {
+		name := prefix + "_" + m.name
+		fmt.Fprintf(&b, "# TYPE %s gauge\n# HELP %s %s\n", name, name, m.help)
+		for _, s := range r.Files {
+			labels := rowLabels(s.Name, opts.Packages)
+			b.WriteString(sample(name, m.value(s), labels, constLabels, opts.Labels))
+		}
+		b.WriteString(sample(name, m.value(r.Total), nil, constLabels, opts.Labels))
+	}
+	b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Labels that identify a file or package
+func rowLabels(name string, packages bool) [][2]string {
+	if packages {
+		return [][2]string{{"package", name}}
+	}
+	return [][2]string{{"package", filepath.Dir(name)}, {"file", name}}
+}
+
+// Formats a sample line
+func sample(name string, value float64, labels [][2]string, constLabels []string, constValues map[string]string) string {
+	pairs := make([]string, 0, len(constLabels)+len(labels))
+	for _, label := range constLabels {
+		pairs = append(pairs, label+`="`+escapeLabel(constValues[label])+`"`)
+	}
+	for _, label := range labels {
+		pairs = append(pairs, label[0]+`="`+escapeLabel(label[1])+`"`)
+	}
+	var labelSet string
+	if len(pairs) > 0 {
+		labelSet = "{" + strings.Join(pairs, ",") + "}"
+	}
+	return name + labelSet + " " + strconv.FormatFloat(value, 'g', -1, 64) + "\n"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
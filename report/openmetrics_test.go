@@ -0,0 +1,43 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintOpenMetrics(t *testing.T) {
+	report := Report{
+		Files: []Summary{{Name: "report/report.go", Blocks: 4, MissingBlocks: 1, Stmts: 10, MissingStmts: 2, BlockCoverage: 75, StmtCoverage: 80}},
+		Total: Summary{Name: "Total", Blocks: 4, MissingBlocks: 1, Stmts: 10, MissingStmts: 2, BlockCoverage: 75, StmtCoverage: 80},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, PrintOpenMetrics(report, &buf, MetricsOptions{}))
+	output := buf.String()
+	assert.Contains(t, output, "# TYPE go_coverage_block_ratio gauge\n")
+	assert.Contains(t, output, `go_coverage_block_ratio{package="report",file="report/report.go"} 0.75`+"\n")
+	assert.Contains(t, output, "go_coverage_block_ratio 0.75\n")
+	assert.Contains(t, output, `go_coverage_missing_statements{package="report",file="report/report.go"} 2`+"\n")
+	assert.Contains(t, output, "go_coverage_statement_ratio 0.8\n")
+	assert.Contains(t, output, "# EOF\n")
+}
+
+func TestPrintOpenMetricsOptions(t *testing.T) {
+	report := Report{
+		Files: []Summary{{Name: `pkg"x`, Blocks: 4, MissingBlocks: 1, BlockCoverage: 75}},
+		Total: Summary{Name: "Total", Blocks: 4, MissingBlocks: 1, BlockCoverage: 75},
+	}
+	var buf bytes.Buffer
+	opts := MetricsOptions{Prefix: "myapp_cov", Labels: map[string]string{"repo": "goverreport", "branch": "main"}, Packages: true}
+	require.NoError(t, PrintOpenMetrics(report, &buf, opts))
+	assert.Contains(t, buf.String(), `myapp_cov_blocks{branch="main",repo="goverreport",package="pkg\"x"} 4`+"\n")
+	assert.Contains(t, buf.String(), `myapp_cov_blocks{branch="main",repo="goverreport"} 4`+"\n")
+}
+
+func TestPrintOpenMetricsInvalidNames(t *testing.T) {
+	assert.Error(t, PrintOpenMetrics(Report{}, new(bytes.Buffer), MetricsOptions{Prefix: "go-coverage"}))
+	assert.Error(t, PrintOpenMetrics(Report{}, new(bytes.Buffer), MetricsOptions{Labels: map[string]string{"1x": ""}}))
+	assert.Error(t, PrintOpenMetrics(Report{}, new(bytes.Buffer), MetricsOptions{Labels: map[string]string{"file": ""}}))
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rewrites the file names of a coverage profile, e.g. to turn the paths of a
+// container or build sandbox into the import paths of the checkout
+type PathMapping struct {
+	From   string // Prefix of the name, or a regular expression if Regexp is set
+	To     string // Replacement, which can refer to the groups of a regular expression ($1)
+	Regexp bool
+}
+
+// Path mapping ready to be applied
+type pathMapper struct {
+	mapping PathMapping
+	pattern *regexp.Regexp
+}
+
+// Compiles the regular expressions of the path mappings
+func compileMappings(mappings []PathMapping) ([]pathMapper, error) {
+	mappers := make([]pathMapper, 0, len(mappings))
+	for _, mapping := range mappings {
+		mapper := pathMapper{mapping: mapping}
+		if mapping.Regexp {
+			pattern, err := regexp.Compile(mapping.From)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid path mapping '%s': %s", mapping.From, err)
+			}
+			mapper.pattern = pattern
+		}
+		mappers = append(mappers, mapper)
+	}
+	return mappers, nil
+}
+
+// Checks that the path mappings are valid
+func ValidatePathMappings(mappings []PathMapping) error {
+	_, err := compileMappings(mappings)
+	return err
+}
+
+// Applies the first mapping that matches a file name
+func mapPath(fileName string, mappers []pathMapper) string {
+	for _, mapper := range mappers {
+		if mapper.pattern != nil {
+			if mapper.pattern.MatchString(fileName) {
+				return mapper.pattern.ReplaceAllString(fileName, mapper.mapping.To)
+			}
+		} else if strings.HasPrefix(fileName, mapper.mapping.From) {
+			return mapper.mapping.To + strings.TrimPrefix(fileName, mapper.mapping.From)
+		}
+	}
+	return fileName
+}
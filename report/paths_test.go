@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sandboxMappings = []PathMapping{
+	{From: "/workspace/src/", To: ""},
+	{From: `^bazel-out/[^/]+/bin/(.*)$`, To: "$1", Regexp: true}}
+
+func TestMapPath(t *testing.T) {
+	assert := assert.New(t)
+	mappers, err := compileMappings(append(sandboxMappings, PathMapping{From: "example.com/", To: "other.com/"}))
+	require.NoError(t, err)
+	assert.Equal("example.com/mod/a.go", mapPath("/workspace/src/example.com/mod/a.go", mappers))
+	assert.Equal("example.com/mod/b.go", mapPath("bazel-out/k8-fastbuild/bin/example.com/mod/b.go", mappers))
+	assert.Equal("other.com/mod/c.go", mapPath("example.com/mod/c.go", mappers))
+	assert.Equal("x/d.go", mapPath("x/d.go", mappers), "Names without a matching mapping are kept")
+
+	assert.NoError(ValidatePathMappings(sandboxMappings))
+	assert.EqualError(ValidatePathMappings([]PathMapping{{From: "(", Regexp: true}}),
+		"Invalid path mapping '(': error parsing regexp: missing closing ): `(`")
+}
+
+func TestGenerateReportWithPathMappings(t *testing.T) {
+	assert := assert.New(t)
+	rep, err := GenerateReport("testdata/sandbox.out", "example.com/mod", sandboxMappings, []string{"/b.go"}, "filename", "asc", false)
+	require.NoError(t, err)
+	require.Len(t, rep.Files, 1, "Exclusions apply to the mapped names")
+	assert.Equal("/a.go", rep.Files[0].Name)
+
+	files, err := LoadFileBlocks("testdata/sandbox.out", ".", "example.com/mod", sandboxMappings, []string{})
+	require.NoError(t, err)
+	assert.Equal("example.com/mod/b.go", files["/b.go"].Profile)
+
+	a, err := LoadAttribution("testdata/tests", "github.com/mcubik/goverreport", []PathMapping{{From: "github.com/mcubik/", To: "x/"}}, []string{})
+	require.NoError(t, err)
+	tests, err := a.FileTests("x/goverreport/report/report.go")
+	require.NoError(t, err)
+	assert.NotEmpty(tests)
+
+	_, err = GenerateReport("testdata/sandbox.out", "", []PathMapping{{From: "(", Regexp: true}}, []string{}, "filename", "asc", false)
+	assert.Error(err)
+	_, err = LoadFileBlocks("testdata/sandbox.out", ".", "", []PathMapping{{From: "(", Regexp: true}}, []string{})
+	assert.Error(err)
+	_, err = LoadAttribution("testdata/tests", "", []PathMapping{{From: "(", Regexp: true}}, []string{})
+	assert.Error(err)
+}
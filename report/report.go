@@ -12,30 +12,61 @@ import (
 
 // Coverage summary for a file or module
 type Summary struct {
-	Name                                       string
-	Blocks, Stmts, MissingBlocks, MissingStmts int
-	BlockCoverage, StmtCoverage                float64
+	Name          string  `json:"name"`
+	Blocks        int     `json:"blocks"`
+	Stmts         int     `json:"stmts"`
+	MissingBlocks int     `json:"missingBlocks"`
+	MissingStmts  int     `json:"missingStmts"`
+	BlockCoverage float64 `json:"blockCoverage"`
+	StmtCoverage  float64 `json:"stmtCoverage"`
+	Lines         int     `json:"lines"`
+	MissingLines  int     `json:"missingLines"`
+	LineCoverage  float64 `json:"lineCoverage"`
+
+	Functions        int     `json:"functions"`
+	MissingFunctions int     `json:"missingFunctions"`
+	FunctionCoverage float64 `json:"functionCoverage"`
+
+	others bool // Aggregates the rows left out by Top, so it isn't a file or package
 }
 
 // Report of the coverage results
 type Report struct {
-	Total Summary   // Global coverage
-	Files []Summary // Coverage by file
+	Total Summary   `json:"total"` // Global coverage
+	Files []Summary `json:"files"` // Coverage by file
+}
+
+// Error reading or parsing a coverage profile
+type ProfileError struct {
+	Err error
+}
+
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("Invalid coverprofile: '%s'", e.Err)
+}
+
+func (e *ProfileError) Unwrap() error {
+	return e.Err
 }
 
 // Generates a coverage report given the coverage profile file, and the following configurations:
+// mappings: rewrites of the file names of the profile, applied before anything else (see PathMapping)
 // exclusions: packages to be excluded (if a package is excluded, all its subpackages are excluded as well)
 // sortBy: the order in which the files will be sorted in the report (see sortResults)
 // order: the direction of the the sorting
-func GenerateReport(coverprofile string, root string, exclusions []string, sortBy, order string, packages bool) (Report, error) {
-	profiles, err := cover.ParseProfiles(coverprofile)
+func GenerateReport(coverprofile string, root string, mappings []PathMapping, exclusions []string, sortBy, order string, packages bool) (Report, error) {
+	mappers, err := compileMappings(mappings)
+	if err != nil {
+		return Report{}, err
+	}
+	profiles, err := ReadProfiles(coverprofile)
 	if err != nil {
-		return Report{}, fmt.Errorf("Invalid coverprofile: '%s'", err)
+		return Report{}, &ProfileError{err}
 	}
 	total := &accumulator{name: "Total"}
 	files := make(map[string]*accumulator)
 	for _, profile := range profiles {
-		fileName := normalizeName(profile.FileName, root, packages)
+		fileName := normalizeName(mapPath(profile.FileName, mappers), root, packages)
 		if isExcluded(fileName, exclusions) {
 			continue
 		}
@@ -89,10 +120,85 @@ func makeReport(total *accumulator, files map[string]*accumulator, sortBy, order
 		Files: fileReports}, nil
 }
 
+// Returns the value of a coverage metric of a summary: block or stmt
+func Metric(s Summary, metric string) (float64, error) {
+	switch metric {
+	case "block":
+		return s.BlockCoverage, nil
+	case "stmt":
+		return s.StmtCoverage, nil
+	default:
+		return 0, fmt.Errorf("Invalid metric '%s', use 'block' or 'stmt'", metric)
+	}
+}
+
+// Returns a copy of a report with the files for which keep returns true.
+// If filterTotal is set, the total is computed again from those files.
+func Filter(r Report, keep func(s Summary) bool, filterTotal bool) Report {
+	kept := make([]Summary, 0, len(r.Files))
+	for _, s := range r.Files {
+		if keep(s) {
+			kept = append(kept, s)
+		}
+	}
+	total := r.Total
+	if filterTotal {
+		total = Aggregate(r.Total.Name, kept)
+	}
+	return Report{Total: total, Files: kept}
+}
+
+// Returns a copy of a report keeping only the first n files (all of them if
+// n is 0) among those with at least minStmts statements. The rest are
+// aggregated in a single "Others" row, so that the rows still add up to
+// the total. Meant to be applied to a sorted report.
+func Top(r Report, n, minStmts int) Report {
+	kept := make([]Summary, 0, len(r.Files))
+	var others []Summary
+	for _, s := range r.Files {
+		if s.Stmts < minStmts || (n > 0 && len(kept) >= n) {
+			others = append(others, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	if len(others) > 0 {
+		row := Aggregate(fmt.Sprintf("Others (%d)", len(others)), others)
+		row.others = true
+		kept = append(kept, row)
+	}
+	return Report{Total: r.Total, Files: kept}
+}
+
+// Compares a report with a previous one and describes the change of the
+// files whose coverage changed, by name, e.g. "stmt +2.50". New files are
+// described as "new". See WithChanges.
+func Changes(previous, current Report) map[string]string {
+	before := make(map[string]Summary, len(previous.Files))
+	for _, s := range previous.Files {
+		before[s.Name] = s
+	}
+	changes := make(map[string]string)
+	for _, s := range current.Files {
+		old, ok := before[s.Name]
+		switch {
+		case !ok:
+			changes[s.Name] = "new"
+		case old.StmtCoverage != s.StmtCoverage:
+			changes[s.Name] = fmt.Sprintf("stmt %+.2f", s.StmtCoverage-old.StmtCoverage)
+		case old.BlockCoverage != s.BlockCoverage:
+			changes[s.Name] = fmt.Sprintf("block %+.2f", s.BlockCoverage-old.BlockCoverage)
+		}
+	}
+	return changes
+}
+
 // Accumulates the coverage of a file and returns a summary
 type accumulator struct {
 	name                                       string
 	blocks, stmts, coveredBlocks, coveredStmts int
+	lines, coveredLines                        int
+	functions, coveredFunctions                int
 }
 
 // Accumulates a profile block
@@ -105,12 +211,46 @@ func (a *accumulator) add(block cover.ProfileBlock) {
 	}
 }
 
+// Accumulates the blocks of a file, together with the lines they span. A
+// line is covered if any of its blocks is.
 func (a *accumulator) addAll(blocks []cover.ProfileBlock) {
+	lines := make(map[int]bool)
 	for _, block := range blocks {
 		a.add(block)
+		for line := block.StartLine; line <= block.EndLine; line++ {
+			lines[line] = lines[line] || block.Count > 0
+		}
+	}
+	a.lines += len(lines)
+	for _, covered := range lines {
+		if covered {
+			a.coveredLines++
+		}
 	}
 }
 
+// Accumulates the totals of another summary
+func (a *accumulator) addSummary(s Summary) {
+	a.blocks += s.Blocks
+	a.stmts += s.Stmts
+	a.coveredBlocks += s.Blocks - s.MissingBlocks
+	a.coveredStmts += s.Stmts - s.MissingStmts
+	a.lines += s.Lines
+	a.coveredLines += s.Lines - s.MissingLines
+	a.functions += s.Functions
+	a.coveredFunctions += s.Functions - s.MissingFunctions
+}
+
+// Aggregates several summaries into a single one, adding up their blocks
+// and statements rather than averaging their coverage
+func Aggregate(name string, summaries []Summary) Summary {
+	acc := &accumulator{name: name}
+	for _, s := range summaries {
+		acc.addSummary(s)
+	}
+	return acc.results()
+}
+
 // Creates a summary with the accumulated values
 func (a *accumulator) results() Summary {
 	return Summary{
@@ -119,54 +259,111 @@ func (a *accumulator) results() Summary {
 		Stmts:         a.stmts,
 		MissingBlocks: a.blocks - a.coveredBlocks,
 		MissingStmts:  a.stmts - a.coveredStmts,
-		BlockCoverage: float64(a.coveredBlocks) / float64(a.blocks) * 100,
-		StmtCoverage:  float64(a.coveredStmts) / float64(a.stmts) * 100}
+		BlockCoverage: percentage(a.coveredBlocks, a.blocks),
+		StmtCoverage:  percentage(a.coveredStmts, a.stmts),
+		Lines:         a.lines,
+		MissingLines:  a.lines - a.coveredLines,
+		LineCoverage:  percentage(a.coveredLines, a.lines),
+
+		Functions:        a.functions,
+		MissingFunctions: a.functions - a.coveredFunctions,
+		FunctionCoverage: percentage(a.coveredFunctions, a.functions)}
+}
+
+// Percentage of covered items. Nothing to cover counts as fully covered.
+func percentage(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) / float64(total) * 100
+}
+
+// SortSummaries sorts summaries in place using the same columns and
+// directions as GenerateReport (see sortResults)
+func SortSummaries(reports []Summary, sortBy, order string) error {
+	return sortResults(reports, sortBy, order)
+}
+
+// Compares two summaries by a sort key
+type compareFunc func(a, b Summary) int
+
+// Sort keys. filename and package both sort by name.
+var sortKeys = map[string]compareFunc{
+	"filename":       func(a, b Summary) int { return strings.Compare(a.Name, b.Name) },
+	"package":        func(a, b Summary) int { return strings.Compare(a.Name, b.Name) },
+	"block":          func(a, b Summary) int { return compareNumbers(a.BlockCoverage, b.BlockCoverage) },
+	"stmt":           func(a, b Summary) int { return compareNumbers(a.StmtCoverage, b.StmtCoverage) },
+	"blocks":         func(a, b Summary) int { return a.Blocks - b.Blocks },
+	"stmts":          func(a, b Summary) int { return a.Stmts - b.Stmts },
+	"missing-blocks": func(a, b Summary) int { return a.MissingBlocks - b.MissingBlocks },
+	"missing-stmts":  func(a, b Summary) int { return a.MissingStmts - b.MissingStmts },
+}
+
+// Names of the sort keys, in the order they are listed to users
+var sortKeyNames = []string{"filename", "package", "block", "stmt", "blocks", "stmts", "missing-blocks", "missing-stmts"}
+
+// Returns the names of the keys a report can be sorted by
+func SortKeys() []string {
+	return append([]string(nil), sortKeyNames...)
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
 }
 
-// Sorts the individual coverage reports by a given column
-// (block --block coverage--, stmt --stmt coverage--, missing-blocks or missing-stmts)
-// and a sorting direction (asc or desc)
+// Sorts the individual coverage reports by a comma separated list of keys
+// (filename, package, block --block coverage--, stmt --stmt coverage--,
+// blocks, stmts, missing-blocks or missing-stmts). Each key can be followed
+// by its direction, e.g. "stmt:desc"; order (asc or desc) is the direction
+// of the keys without one. The sort is stable, with the name as the last key.
 func sortResults(reports []Summary, mode string, order string) error {
-	var reverse bool
-	var cmp func(i, j int) bool
-	switch order {
-	case "asc":
-		reverse = false
-	case "desc":
-		reverse = true
-	default:
-		return errors.New("Order must be either asc or desc")
+	less, err := sortOrder(mode, order)
+	if err != nil {
+		return err
 	}
-	switch mode {
-	case "filename", "package":
-		cmp = func(i, j int) bool {
-			return reports[i].Name < reports[j].Name
-		}
-	case "block":
-		cmp = func(i, j int) bool {
-			return reports[i].BlockCoverage < reports[j].BlockCoverage
-		}
-	case "stmt":
-		cmp = func(i, j int) bool {
-			return reports[i].StmtCoverage < reports[j].StmtCoverage
+	sort.SliceStable(reports, func(i, j int) bool {
+		return less(reports[i], reports[j])
+	})
+	return nil
+}
+
+// Builds the comparison of a sort, see sortResults
+func sortOrder(mode string, order string) (func(a, b Summary) bool, error) {
+	if order != "asc" && order != "desc" {
+		return nil, errors.New("Order must be either asc or desc")
+	}
+	var compare []compareFunc
+	for _, key := range strings.Split(mode, ",") {
+		name, direction, found := strings.Cut(strings.TrimSpace(key), ":")
+		if !found {
+			direction = order
 		}
-	case "missing-blocks":
-		cmp = func(i, j int) bool {
-			return reports[i].MissingBlocks < reports[j].MissingBlocks
+		cmp, ok := sortKeys[name]
+		if !ok {
+			return nil, fmt.Errorf("Invalid sort column '%s', must be one of %s", name, strings.Join(sortKeyNames, ", "))
 		}
-	case "missing-stmts":
-		cmp = func(i, j int) bool {
-			return reports[i].MissingStmts < reports[j].MissingStmts
+		switch direction {
+		case "asc":
+			compare = append(compare, cmp)
+		case "desc":
+			compare = append(compare, func(a, b Summary) int { return cmp(b, a) })
+		default:
+			return nil, fmt.Errorf("Invalid direction '%s' for sort key %s, must be either asc or desc", direction, name)
 		}
-	default:
-		return errors.New("Invalid sort colum, must be one of filename, package, block, stmt, missing-blocks or missing-stmts")
 	}
-	sort.Slice(reports, func(i, j int) bool {
-		if reverse {
-			return !cmp(i, j)
-		} else {
-			return cmp(i, j)
+	compare = append(compare, sortKeys["filename"])
+	return func(a, b Summary) bool {
+		for _, cmp := range compare {
+			if c := cmp(a, b); c != 0 {
+				return c < 0
+			}
 		}
-	})
-	return nil
+		return false
+	}, nil
 }
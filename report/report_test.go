@@ -1,9 +1,11 @@
 package report
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
 )
 
 var results []Summary
@@ -24,6 +26,13 @@ func init() {
 	results = []Summary{cover1, cover2}
 }
 
+func TestSortKeys(t *testing.T) {
+	for _, key := range SortKeys() {
+		assert.Contains(t, sortKeys, key)
+	}
+	assert.Len(t, SortKeys(), len(sortKeys))
+}
+
 func TestSortByFileName(t *testing.T) {
 	assert.NoError(t, sortResults(results, "filename", "asc"))
 	assert.Equal(t, results, []Summary{cover1, cover2})
@@ -57,7 +66,7 @@ func TestInvalidParameters(t *testing.T) {
 
 func TestReport(t *testing.T) {
 	assert := assert.New(t)
-	report, err := GenerateReport("../sample_coverage.out", "", []string{}, "block", "desc", false)
+	report, err := GenerateReport("../sample_coverage.out", "", nil, []string{}, "block", "desc", false)
 	assert.NoError(err)
 	assert.InDelta(81.4, report.Total.BlockCoverage, 0.1)
 	assert.InDelta(81.9, report.Total.StmtCoverage, 0.1)
@@ -66,6 +75,153 @@ func TestReport(t *testing.T) {
 }
 
 func TestInvalidCoverProfile(t *testing.T) {
-	_, err := GenerateReport("../xxx.out", "", []string{}, "block", "desc", false)
+	_, err := GenerateReport("../xxx.out", "", nil, []string{}, "block", "desc", false)
+	assert.Error(t, err)
+}
+
+func TestAggregate(t *testing.T) {
+	assert := assert.New(t)
+	s := Aggregate("pkg", []Summary{
+		{Blocks: 10, MissingBlocks: 5, Stmts: 20, MissingStmts: 0},
+		{Blocks: 30, MissingBlocks: 5, Stmts: 20, MissingStmts: 10}})
+	assert.Equal("pkg", s.Name)
+	assert.Equal(40, s.Blocks)
+	assert.Equal(10, s.MissingBlocks)
+	assert.InDelta(75, s.BlockCoverage, 0.01)
+	assert.InDelta(75, s.StmtCoverage, 0.01)
+}
+
+func TestLineCoverage(t *testing.T) {
+	assert := assert.New(t)
+	acc := &accumulator{name: "a.go"}
+	acc.addAll([]cover.ProfileBlock{
+		{StartLine: 1, EndLine: 3, NumStmt: 2, Count: 1},
+		{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 0},
+		{StartLine: 7, EndLine: 7, NumStmt: 1, Count: 0}})
+	s := acc.results()
+	assert.Equal(6, s.Lines)
+	assert.Equal(3, s.MissingLines, "A line is covered if any of its blocks is")
+	assert.InDelta(50, s.LineCoverage, 0.01)
+
+	s = Aggregate("pkg", []Summary{s, {Lines: 4, MissingLines: 0}})
+	assert.Equal(10, s.Lines)
+	assert.InDelta(70, s.LineCoverage, 0.01)
+	assert.Equal(100.0, Aggregate("empty", nil).LineCoverage, "Nothing to cover")
+}
+
+func TestChanges(t *testing.T) {
+	assert := assert.New(t)
+	previous := Report{Files: []Summary{
+		{Name: "a.go", BlockCoverage: 50, StmtCoverage: 50},
+		{Name: "b.go", BlockCoverage: 50, StmtCoverage: 50},
+		{Name: "c.go", BlockCoverage: 50, StmtCoverage: 50}}}
+	current := Report{Files: []Summary{
+		{Name: "a.go", BlockCoverage: 50, StmtCoverage: 50},
+		{Name: "b.go", BlockCoverage: 60, StmtCoverage: 47.5},
+		{Name: "c.go", BlockCoverage: 40, StmtCoverage: 50},
+		{Name: "d.go", BlockCoverage: 10, StmtCoverage: 10}}}
+	assert.Equal(map[string]string{"b.go": "stmt -2.50", "c.go": "block -10.00", "d.go": "new"}, Changes(previous, current))
+}
+
+func TestTop(t *testing.T) {
+	assert := assert.New(t)
+	rep := Report{
+		Total: Summary{Name: "Total", Stmts: 111},
+		Files: []Summary{
+			{Name: "a.go", Stmts: 50, MissingStmts: 25, Blocks: 10, MissingBlocks: 5},
+			{Name: "b.go", Stmts: 40, MissingStmts: 10, Blocks: 10, MissingBlocks: 2},
+			{Name: "c.go", Stmts: 20, MissingStmts: 0, Blocks: 4, MissingBlocks: 0},
+			{Name: "d.go", Stmts: 1, MissingStmts: 1, Blocks: 1, MissingBlocks: 1}}}
+
+	top := Top(rep, 2, 0)
+	assert.Len(top.Files, 3)
+	assert.Equal("b.go", top.Files[1].Name)
+	others := top.Files[2]
+	assert.Equal("Others (2)", others.Name)
+	assert.Equal(21, others.Stmts)
+	assert.Equal(1, others.MissingStmts)
+	assert.Equal(rep.Total, top.Total)
+
+	top = Top(rep, 0, 10)
+	assert.Equal([]string{"a.go", "b.go", "c.go", "Others (1)"}, summaryNames(top.Files))
+
+	top = Top(rep, 1, 30)
+	assert.Equal([]string{"a.go", "Others (3)"}, summaryNames(top.Files))
+
+	assert.Equal(rep, Top(rep, 0, 0), "No filters")
+	assert.Equal(rep, Top(rep, 10, 0), "Less files than the limit")
+}
+
+func summaryNames(summaries []Summary) []string {
+	names := make([]string, 0, len(summaries))
+	for _, s := range summaries {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestFilter(t *testing.T) {
+	assert := assert.New(t)
+	rep := Report{
+		Total: Summary{Name: "Total", Stmts: 30, MissingStmts: 15},
+		Files: []Summary{
+			{Name: "a.go", Stmts: 10, MissingStmts: 10},
+			{Name: "b.go", Stmts: 20, MissingStmts: 5}}}
+	untested := func(s Summary) bool { return s.Stmts == s.MissingStmts }
+
+	filtered := Filter(rep, untested, false)
+	assert.Equal([]string{"a.go"}, summaryNames(filtered.Files))
+	assert.Equal(rep.Total, filtered.Total)
+
+	filtered = Filter(rep, untested, true)
+	assert.Equal("Total", filtered.Total.Name)
+	assert.Equal(10, filtered.Total.Stmts)
+	assert.Equal(10, filtered.Total.MissingStmts)
+
+	filtered = Filter(rep, func(Summary) bool { return false }, true)
+	assert.Empty(filtered.Files)
+	assert.Equal(Summary{Name: "Total", BlockCoverage: 100, StmtCoverage: 100, LineCoverage: 100, FunctionCoverage: 100}, filtered.Total,
+		"An empty selection has nothing left to cover")
+}
+
+func TestMetric(t *testing.T) {
+	s := Summary{BlockCoverage: 10, StmtCoverage: 20}
+	value, err := Metric(s, "block")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, value)
+	value, err = Metric(s, "stmt")
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value)
+	_, err = Metric(s, "xxx")
 	assert.Error(t, err)
 }
+
+func TestSortByMultipleKeys(t *testing.T) {
+	a := Summary{Name: "a", StmtCoverage: 50, MissingStmts: 5, Stmts: 10}
+	b := Summary{Name: "b", StmtCoverage: 50, MissingStmts: 10, Stmts: 20}
+	c := Summary{Name: "c", StmtCoverage: 80, MissingStmts: 2, Stmts: 10}
+	d := Summary{Name: "d", StmtCoverage: 50, MissingStmts: 5, Stmts: 10}
+	reports := []Summary{d, c, b, a}
+	assert.NoError(t, sortResults(reports, "stmt,missing-stmts:desc", "asc"))
+	assert.Equal(t, []Summary{b, a, d, c}, reports)
+
+	// The name is the last key, in ascending order
+	assert.NoError(t, sortResults(reports, "stmts", "desc"))
+	assert.Equal(t, []Summary{b, a, c, d}, reports)
+	assert.NoError(t, sortResults(reports, " stmt:desc , blocks", "asc"))
+	assert.Equal(t, []Summary{c, a, b, d}, reports)
+
+	assert.Error(t, sortResults(reports, "stmt:up", "asc"))
+	assert.Error(t, sortResults(reports, "stmt,", "asc"))
+}
+
+func TestSortEqualElementsDescending(t *testing.T) {
+	reports := make([]Summary, 0, 20)
+	for i := 0; i < 20; i++ {
+		reports = append(reports, Summary{Name: fmt.Sprintf("file%02d", 19-i), BlockCoverage: 50})
+	}
+	assert.NoError(t, sortResults(reports, "block", "desc"))
+	for i, s := range reports {
+		assert.Equal(t, fmt.Sprintf("file%02d", i), s.Name)
+	}
+}
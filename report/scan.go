@@ -0,0 +1,148 @@
+package report
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Adds to a report the source files of a module that don't appear in the
+// coverprofile, usually because their packages have no tests, as rows
+// without coverage. The total is updated accordingly. dir is the root of the
+// module; files excluded by build constraints, evaluated with the given build
+// tags, are skipped. root, exclusions, sortBy, order and packages work as in
+// GenerateReport.
+func AddUntestedSources(r Report, dir, root string, tags, exclusions []string, sortBy, order string, packages bool) (Report, error) {
+	module := modulePath(filepath.Join(dir, "go.mod"))
+	ctx := build.Default
+	ctx.BuildTags = tags
+	known := make(map[string]bool, len(r.Files))
+	for _, s := range r.Files {
+		known[s.Name] = true
+	}
+	missing := make(map[string]*accumulator)
+	var names []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file != dir && skipDir(file, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+			return nil
+		}
+		if match, err := ctx.MatchFile(filepath.Dir(file), d.Name()); err != nil || !match {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		name := normalizeName(path.Join(module, filepath.ToSlash(rel)), root, packages)
+		if known[name] || isExcluded(name, exclusions) {
+			return nil
+		}
+		acc, ok := missing[name]
+		if !ok {
+			acc = &accumulator{name: name}
+			missing[name] = acc
+			names = append(names, name)
+		}
+		return countStatements(file, acc)
+	})
+	if err != nil {
+		return r, err
+	}
+	files := append([]Summary{}, r.Files...)
+	totals := []Summary{r.Total}
+	for _, name := range names {
+		if missing[name].blocks == 0 {
+			// Nothing to instrument, e.g. only type declarations
+			continue
+		}
+		s := missing[name].results()
+		files = append(files, s)
+		totals = append(totals, s)
+	}
+	if err := sortResults(files, sortBy, order); err != nil {
+		return r, err
+	}
+	return Report{Total: Aggregate(r.Total.Name, totals), Files: files}, nil
+}
+
+// Whether a directory isn't part of the module: hidden directories,
+// testdata, vendor and nested modules
+func skipDir(dir, name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// Counts the statements and functions of a source file as "go test -cover"
+// would, accumulating them as not covered. Every statement counts once, as
+// for cmd/cover, but each list of statements (a body, a branch or a case)
+// makes a single block, as the exact split of the blocks changes between Go
+// versions.
+func countStatements(file string, acc *accumulator) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	acc.addAll(statementBlocks(fset, f))
+	acc.functions += len(declaredFunctions(fset, f))
+	return nil
+}
+
+// Blocks of the statement lists of a file, not covered
+func statementBlocks(fset *token.FileSet, f *ast.File) []cover.ProfileBlock {
+	var blocks []cover.ProfileBlock
+	add := func(start, end token.Pos, list []ast.Stmt) {
+		from, to := fset.Position(start), fset.Position(end)
+		blocks = append(blocks, cover.ProfileBlock{
+			StartLine: from.Line, StartCol: from.Column,
+			EndLine: to.Line, EndCol: to.Column,
+			NumStmt: len(list)})
+	}
+	clauses := make(map[*ast.BlockStmt]bool)
+	ast.Inspect(f, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SwitchStmt:
+			clauses[n.Body] = true
+		case *ast.TypeSwitchStmt:
+			clauses[n.Body] = true
+		case *ast.SelectStmt:
+			clauses[n.Body] = true
+		case *ast.BlockStmt:
+			// The body of a switch or select holds clauses, not statements
+			if !clauses[n] {
+				add(n.Lbrace, n.Rbrace, n.List)
+			}
+		case *ast.CaseClause:
+			add(n.Colon, n.End(), n.Body)
+		case *ast.CommClause:
+			add(n.Colon, n.End(), n.Body)
+		case *ast.IfStmt:
+			// An else if is a block holding the if statement
+			if elseIf, ok := n.Else.(*ast.IfStmt); ok {
+				add(n.Body.End(), elseIf.End(), []ast.Stmt{elseIf})
+			}
+		}
+		return true
+	})
+	return blocks
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func moduleReport(t *testing.T, packages bool) Report {
+	rep, err := GenerateReport("testdata/module.out", "example.com/mod", nil, []string{}, "filename", "asc", packages)
+	require.NoError(t, err)
+	return rep
+}
+
+func TestAddUntestedSources(t *testing.T) {
+	assert := assert.New(t)
+	rep, err := AddUntestedSources(moduleReport(t, false), "testdata/module", "example.com/mod", nil, []string{}, "filename", "asc", false)
+	require.NoError(t, err)
+	assert.Equal([]string{"/a.go", "/untested/b.go"}, summaryNames(rep.Files))
+	b := rep.Files[1]
+	assert.Equal(9, b.Blocks)
+	assert.Equal(12, b.Stmts)
+	assert.Equal(12, b.MissingStmts)
+	assert.Equal(0.0, b.StmtCoverage)
+	assert.Equal(1, b.Functions, "Function literals aren't functions")
+	assert.Equal(1, b.MissingFunctions)
+	assert.Equal("Total", rep.Total.Name)
+	assert.Equal(13, rep.Total.Stmts)
+	assert.Equal(12, rep.Total.MissingStmts)
+}
+
+func TestAddUntestedPackages(t *testing.T) {
+	assert := assert.New(t)
+	rep, err := AddUntestedSources(moduleReport(t, true), "testdata/module", "example.com/mod", nil, []string{}, "stmt", "asc", true)
+	require.NoError(t, err)
+	assert.Equal([]string{"./untested", "."}, summaryNames(rep.Files))
+}
+
+func TestAddUntestedSourcesExclusions(t *testing.T) {
+	rep, err := AddUntestedSources(moduleReport(t, false), "testdata/module", "example.com/mod", nil, []string{"/untested"}, "filename", "asc", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a.go"}, summaryNames(rep.Files))
+	assert.Equal(t, 1, rep.Total.Stmts)
+}
+
+func TestAddUntestedSourcesErrors(t *testing.T) {
+	_, err := AddUntestedSources(moduleReport(t, false), "testdata/xxx", "", nil, []string{}, "filename", "asc", false)
+	assert.Error(t, err)
+	_, err = AddUntestedSources(moduleReport(t, false), "testdata/module", "", nil, []string{}, "xxx", "asc", false)
+	assert.Error(t, err)
+	assert.Error(t, countStatements("testdata/badtests/TestBroken.out", &accumulator{}))
+}
+
+func TestAddUntestedSourcesTags(t *testing.T) {
+	rep, err := AddUntestedSources(moduleReport(t, false), "testdata/module", "example.com/mod", []string{"integration"}, []string{}, "filename", "asc", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a.go", "/integration/i.go", "/untested/b.go"}, summaryNames(rep.Files))
+}
+
+func TestCountStatements(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), "p.go")
+	require.NoError(t, os.WriteFile(file, []byte(`package p
+
+func Empty() {}
+
+func F(c chan int, x int) int {
+loop:
+	for x > 0 {
+		x--
+	}
+	select {
+	case v := <-c:
+		return v
+	default:
+	}
+done:
+	x++
+	switch v := interface{}(x).(type) {
+	case int:
+		return v
+	}
+	if x > 1 {
+		goto loop
+	} else if x > 2 {
+		goto done
+	}
+	return 0
+}
+`), 0o600))
+	acc := &accumulator{}
+	require.NoError(t, countStatements(file, acc))
+	// The same statements as "go test -cover"
+	assert.Equal(12, acc.stmts)
+	assert.Equal(0, acc.coveredStmts)
+	assert.Equal(9, acc.blocks, "A block by list of statements and else if")
+	assert.Equal(2, acc.functions)
+}
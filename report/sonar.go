@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Generic test coverage format of SonarQube
+type sonarCoverage struct {
+	XMLName xml.Name    `xml:"coverage"`
+	Version int         `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path  string      `xml:"path,attr"`
+	Lines []sonarLine `xml:"lineToCover"`
+}
+
+type sonarLine struct {
+	Number  int  `xml:"lineNumber,attr"`
+	Covered bool `xml:"covered,attr"`
+}
+
+// PrintSonar prints the coverage blocks in the SonarQube generic test
+// coverage format. A line is covered if any of the blocks it belongs to was
+// executed. Files are named by their source path relative to dir, the module
+// directory or, if they weren't found, by their name in the profile after the
+// path mappings.
+func PrintSonar(files map[string]*FileBlocks, w io.Writer, dir string) error {
+	coverage := sonarCoverage{Version: 1, Files: make([]sonarFile, 0, len(files))}
+	for _, f := range files {
+		lines := make(map[int]bool)
+		for _, block := range f.Blocks {
+			for line := block.StartLine; line <= block.EndLine; line++ {
+				lines[line] = lines[line] || block.Count > 0
+			}
+		}
+		file := sonarFile{Path: sonarPath(f, dir), Lines: make([]sonarLine, 0, len(lines))}
+		for number, covered := range lines {
+			file.Lines = append(file.Lines, sonarLine{number, covered})
+		}
+		sort.Slice(file.Lines, func(i, j int) bool {
+			return file.Lines[i].Number < file.Lines[j].Number
+		})
+		coverage.Files = append(coverage.Files, file)
+	}
+	sort.Slice(coverage.Files, func(i, j int) bool {
+		return coverage.Files[i].Path < coverage.Files[j].Path
+	})
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(coverage); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Path of a file relative to the repository
+func sonarPath(f *FileBlocks, dir string) string {
+	if f.Path != "" {
+		if path, err := filepath.Rel(dir, f.Path); err == nil {
+			return filepath.ToSlash(path)
+		}
+	}
+	return f.Profile
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func TestPrintSonar(t *testing.T) {
+	files := map[string]*FileBlocks{
+		"/report/report.go": {
+			Name:    "/report/report.go",
+			Profile: "src/report/report.go",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 3, EndLine: 4, Count: 1},
+				{StartLine: 4, EndLine: 5, Count: 0},
+				{StartLine: 7, EndLine: 7, Count: 0}}},
+		"/main.go": {
+			Name:    "/main.go",
+			Profile: "github.com/mcubik/goverreport/main.go",
+			Path:    "main.go",
+			Blocks:  []cover.ProfileBlock{{StartLine: 1, EndLine: 1, Count: 2}}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, PrintSonar(files, &buf, "."))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<coverage version="1">
+  <file path="main.go">
+    <lineToCover lineNumber="1" covered="true"></lineToCover>
+  </file>
+  <file path="src/report/report.go">
+    <lineToCover lineNumber="3" covered="true"></lineToCover>
+    <lineToCover lineNumber="4" covered="true"></lineToCover>
+    <lineToCover lineNumber="5" covered="false"></lineToCover>
+    <lineToCover lineNumber="7" covered="false"></lineToCover>
+  </file>
+</coverage>
+`, buf.String())
+}
+
+func TestSonarPathFallsBackToProfile(t *testing.T) {
+	assert.Equal(t, "example.com/x.go", sonarPath(&FileBlocks{Profile: "example.com/x.go"}, "."))
+}
+
+func TestSonarPathRelativeToModule(t *testing.T) {
+	f := &FileBlocks{Profile: "example.com/report/x.go", Path: "../report/x.go"}
+	assert.Equal(t, "report/x.go", sonarPath(f, ".."))
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Coverage blocks of a file, together with the location of its source code
+type FileBlocks struct {
+	Name    string // Name of the file as it appears in the report
+	Profile string // Name of the file in the coverprofile after the path mappings, usually an import path
+	Path    string // Path of the source file, empty if it couldn't be found
+	Blocks  []cover.ProfileBlock
+}
+
+// Loads the blocks of every file in a coverage profile, indexed by the
+// file names used in the report. The source files are looked up in dir, the
+// directory of the module. root, mappings and exclusions work as in
+// GenerateReport.
+func LoadFileBlocks(coverprofile, dir, root string, mappings []PathMapping, exclusions []string) (map[string]*FileBlocks, error) {
+	mappers, err := compileMappings(mappings)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ReadProfiles(coverprofile)
+	if err != nil {
+		return nil, &ProfileError{err}
+	}
+	module := modulePath(filepath.Join(dir, "go.mod"))
+	files := make(map[string]*FileBlocks)
+	for _, profile := range profiles {
+		profileName := mapPath(profile.FileName, mappers)
+		fileName := normalizeName(profileName, root, false)
+		if isExcluded(fileName, exclusions) {
+			continue
+		}
+		fileBlocks, ok := files[fileName]
+		if !ok {
+			fileBlocks = &FileBlocks{
+				Name:    fileName,
+				Profile: profileName,
+				Path:    sourcePath(profileName, dir, root, module)}
+			files[fileName] = fileBlocks
+		}
+		fileBlocks.Blocks = append(fileBlocks.Blocks, profile.Blocks...)
+	}
+	return files, nil
+}
+
+// Finds the source of a profile file in the module in dir. Profiles name
+// files by import path, so the module path (or the configured root) is
+// stripped to get a path relative to the module directory.
+func sourcePath(fileName, dir, root, module string) string {
+	candidates := []string{fileName}
+	for _, prefix := range []string{module, root} {
+		if prefix != "" && strings.HasPrefix(fileName, prefix+"/") {
+			candidates = append(candidates, strings.TrimPrefix(fileName, prefix+"/"))
+		}
+	}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		candidate := candidates[i]
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(dir, candidate)
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Clean(candidate)
+		}
+	}
+	return ""
+}
+
+// Reads the module path declared in a go.mod file, or returns an empty
+// string if it can't be read
+func modulePath(gomod string) string {
+	// #nosec G304 -- reads the go.mod file of the current module
+	file, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
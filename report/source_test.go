@@ -0,0 +1,40 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileBlocks(t *testing.T) {
+	assert := assert.New(t)
+	files, err := LoadFileBlocks("../sample_coverage.out", ".", "github.com/mcubik/goverreport/report", nil, []string{})
+	require.NoError(t, err)
+	assert.Len(files, 3)
+	assert.Equal("report.go", files["/report.go"].Path)
+	assert.NotEmpty(files["/report.go"].Blocks)
+	assert.Equal("", files["github.com/mcubik/goverreport/main.go"].Path, "Outside the working directory")
+
+	files, err = LoadFileBlocks("../sample_coverage.out", "..", "github.com/mcubik/goverreport", nil, []string{})
+	require.NoError(t, err)
+	assert.Equal(filepath.Join("..", "main.go"), files["/main.go"].Path, "Relative to the module directory")
+
+	_, err = LoadFileBlocks("../xxx.out", ".", "", nil, []string{})
+	assert.Error(err)
+}
+
+func TestSourcePath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("view.go", sourcePath("example.com/mod/view.go", ".", "", "example.com/mod"))
+	assert.Equal("view.go", sourcePath("example.com/mod/view.go", ".", "example.com/mod", ""))
+	assert.Equal("", sourcePath("example.com/mod/xxx.go", ".", "", "example.com/mod"))
+	assert.Equal("testdata/tests/TestReport.out", sourcePath("testdata/tests/TestReport.out", ".", "", ""))
+	assert.Equal("testdata/module/a.go", sourcePath("example.com/mod/a.go", "testdata/module", "", "example.com/mod"))
+}
+
+func TestModulePath(t *testing.T) {
+	assert.Equal(t, "github.com/mcubik/goverreport", modulePath("../go.mod"))
+	assert.Equal(t, "", modulePath("xxx"))
+}
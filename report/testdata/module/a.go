@@ -0,0 +1,5 @@
+package mod
+
+func A() int {
+	return 1
+}
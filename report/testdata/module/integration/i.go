@@ -0,0 +1,7 @@
+//go:build integration
+
+package integration
+
+func I() int {
+	return 1
+}
@@ -0,0 +1,30 @@
+package untested
+
+import "fmt"
+
+// Types only have no statements
+type T struct{}
+
+var handler = func() {
+	fmt.Println("literal")
+}
+
+func B(x int) int {
+	y := x * 2
+	if y > 10 {
+		return y
+	} else if y > 5 {
+		return 5
+	} else {
+		y++
+	}
+	for i := 0; i < x; i++ {
+		y += i
+	}
+	switch y {
+	case 1:
+		return 1
+	default:
+	}
+	return y
+}
@@ -0,0 +1,3 @@
+package untested
+
+type U struct{}
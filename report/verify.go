@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Problem found checking a coverage profile against the current sources
+type SourceIssue struct {
+	File    string `json:"file"`
+	Kind    string `json:"kind"` // missing, stale or out-of-range
+	Message string `json:"message"`
+}
+
+// Kinds of source issues
+const (
+	SourceMissing    = "missing"
+	SourceStale      = "stale"
+	SourceOutOfRange = "out-of-range"
+)
+
+// Whether the issue shows that the profile doesn't match the source. A
+// stale file may still match it, as checkouts and downloads of the profile
+// change the modification times.
+func (i SourceIssue) Mismatch() bool {
+	return i.Kind != SourceStale
+}
+
+// Cross-references the blocks of a coverage profile with the source files,
+// as loaded by LoadFileBlocks. Reports the files that can't be found and the
+// blocks that point past the end of their file or of their lines, which
+// show that the sources changed. Files modified after the profile was
+// written are reported as stale, which isn't a mismatch (see Mismatch). The
+// issues are sorted by file name.
+func VerifySources(coverprofile string, files map[string]*FileBlocks) ([]SourceIssue, error) {
+	profileInfo, err := os.Stat(coverprofile)
+	if err != nil {
+		return nil, &ProfileError{err}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var issues []SourceIssue
+	for _, name := range names {
+		file := files[name]
+		if file.Path == "" {
+			issues = append(issues, SourceIssue{name, SourceMissing, "source file not found"})
+			continue
+		}
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(profileInfo.ModTime()) {
+			issues = append(issues, SourceIssue{name, SourceStale, "source file modified after the coverprofile"})
+		}
+		// #nosec G304 -- reads the sources named in the coverprofile
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if issue, ok := checkBlockRanges(file, bytes.Split(data, []byte("\n"))); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// Checks that the blocks of a file are within its lines, reporting the
+// number of blocks out of range and the first of them
+func checkBlockRanges(file *FileBlocks, lines [][]byte) (SourceIssue, bool) {
+	inRange := func(line, col int) bool {
+		return line >= 1 && line <= len(lines) && col >= 1 && col <= len(lines[line-1])+1
+	}
+	count := 0
+	var first string
+	for _, b := range file.Blocks {
+		if inRange(b.StartLine, b.StartCol) && inRange(b.EndLine, b.EndCol) {
+			continue
+		}
+		if count == 0 {
+			first = fmt.Sprintf("%d.%d,%d.%d", b.StartLine, b.StartCol, b.EndLine, b.EndCol)
+		}
+		count++
+	}
+	if count == 0 {
+		return SourceIssue{}, false
+	}
+	return SourceIssue{file.Name, SourceOutOfRange,
+		fmt.Sprintf("%d blocks out of range, first at %s, the file has %d lines", count, first, len(lines))}, true
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+func TestVerifySources(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "coverage.out")
+	fresh := filepath.Join(dir, "fresh.go")
+	stale := filepath.Join(dir, "stale.go")
+	source := []byte("package mod\n\nfunc A() int {\n\treturn 1\n}\n")
+	for _, file := range []string{profile, fresh, stale} {
+		require.NoError(t, os.WriteFile(file, source, 0600))
+	}
+	now := time.Now()
+	require.NoError(t, os.Chtimes(fresh, now.Add(-time.Hour), now.Add(-time.Hour)))
+	require.NoError(t, os.Chtimes(profile, now, now))
+	require.NoError(t, os.Chtimes(stale, now.Add(time.Hour), now.Add(time.Hour)))
+
+	files := map[string]*FileBlocks{
+		"fresh.go":   {Name: "fresh.go", Path: fresh, Blocks: []cover.ProfileBlock{{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2}}},
+		"stale.go":   {Name: "stale.go", Path: stale, Blocks: []cover.ProfileBlock{{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2}}},
+		"deleted.go": {Name: "deleted.go"},
+		"shrunk.go": {Name: "shrunk.go", Path: fresh, Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2},
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 3},
+			{StartLine: 8, StartCol: 1, EndLine: 12, EndCol: 2}}},
+	}
+	issues, err := VerifySources(profile, files)
+	assert.NoError(err)
+	assert.Equal([]SourceIssue{
+		{"deleted.go", SourceMissing, "source file not found"},
+		{"shrunk.go", SourceOutOfRange, "2 blocks out of range, first at 3.14,5.3, the file has 6 lines"},
+		{"stale.go", SourceStale, "source file modified after the coverprofile"}}, issues)
+	assert.True(issues[1].Mismatch())
+	assert.False(issues[2].Mismatch(), "Stale files may still match the profile")
+
+	_, err = VerifySources(filepath.Join(dir, "xxx.out"), files)
+	assert.Error(err)
+	files["gone.go"] = &FileBlocks{Name: "gone.go", Path: filepath.Join(dir, "gone.go")}
+	_, err = VerifySources(profile, files)
+	assert.Error(err)
+}
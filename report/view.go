@@ -1,40 +1,143 @@
 package report
 
 import (
-	"fmt"
+	"encoding/json"
+	"html/template"
 	"io"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/tw"
 )
 
+// Options of the tabular outputs
+type tableOptions struct {
+	columns  []Column
+	bar      *Column           // Metric shown as a bar chart, if any
+	barWidth int               // Width of the bars in characters
+	bands    *ColorBands       // Coverage bands used to color the rows, if any
+	groups   []Group           // Files grouped by package, if any
+	changes  map[string]string // Changes highlighted next to the names of the rows
+}
+
+// Coverage bands used to color the rows of the table: red below Red,
+// yellow below Yellow and green otherwise
+type ColorBands struct {
+	Metric      string // Metric that decides the band: block or stmt
+	Red, Yellow float64
+}
+
+// Default coverage bands
+var DefaultColorBands = ColorBands{Metric: "stmt", Red: 50, Yellow: 80}
+
+const (
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiGreen  = "\x1b[32m"
+	ansiReset  = "\x1b[0m"
+)
+
+// TableOption configures the tabular outputs: PrintTable and PrintHTML
+type TableOption func(*tableOptions)
+
+// WithColumns selects the columns of the table and their order
+func WithColumns(columns []Column) TableOption {
+	return func(o *tableOptions) {
+		if len(columns) > 0 {
+			o.columns = columns
+		}
+	}
+}
+
+// WithBar adds a column with a bar chart of a metric, block or stmt coverage
+func WithBar(metric string, width int) TableOption {
+	return func(o *tableOptions) {
+		if column, ok := findColumn(metric); ok && column.round {
+			o.bar, o.barWidth = &column, width
+		}
+	}
+}
+
+// WithColors colors every row of the table by its coverage band
+func WithColors(bands ColorBands) TableOption {
+	return func(o *tableOptions) {
+		if _, ok := findColumn(bands.Metric); ok {
+			o.bands = &bands
+		}
+	}
+}
+
+// WithGroups shows the files grouped by package, each group followed by its
+// subtotal, instead of the files of the report
+func WithGroups(groups []Group) TableOption {
+	return func(o *tableOptions) {
+		o.groups = groups
+	}
+}
+
+// WithChanges highlights the changes of the rows, as described by Changes,
+// next to their names, e.g. "main.go (stmt +2.50)"
+func WithChanges(changes map[string]string) TableOption {
+	return func(o *tableOptions) {
+		o.changes = changes
+	}
+}
+
+// Row of a tabular output
+type tableRow struct {
+	Summary
+	subtotal bool
+}
+
+// Rows of a report: its files, or the rows grouped by package (see
+// groupedRows)
+func (o tableOptions) rows(r Report) []tableRow {
+	if o.groups == nil {
+		rows := make([]tableRow, 0, len(r.Files))
+		for _, s := range r.Files {
+			rows = append(rows, tableRow{s, false})
+		}
+		return rows
+	}
+	return groupedRows(r, o.groups)
+}
+
+func newTableOptions(opts []TableOption) tableOptions {
+	columns, _ := ParseColumns(DefaultColumns)
+	options := tableOptions{columns: columns}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 // PrintTable prints the report to the terminal
-func PrintTable(r Report, w io.Writer, packages bool) error {
+func PrintTable(r Report, w io.Writer, packages bool, opts ...TableOption) error {
+	options := newTableOptions(opts)
+
 	// Create table with ASCII border style for compatibility with tests
 	table := tablewriter.NewTable(w,
 		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleASCII)),
 		tablewriter.WithHeaderAutoFormat(tw.Off), // Disable auto-formatting to preserve case
 	)
 
-	// Determine header label based on packages flag
-	item := "File"
-	if packages {
-		item = "Package"
+	// Header label of the name column depends on the packages flag
+	header := headers(options.columns, packages)
+	if options.bar != nil {
+		header = append(header, strings.TrimSuffix(options.bar.Header, " %"))
 	}
-
-	// Set headers to match all columns from makeRow
-	table.Header(item, "Blocks", "Missing", "Stmts", "Missing", "Block cover %", "Stmt cover %")
+	table.Header(header)
 
 	// Add rows for all files
-	for _, s := range r.Files {
-		err := table.Append(makeRow(s))
+	for _, s := range options.rows(r) {
+		err := table.Append(options.row(s.Summary))
 		if err != nil {
 			return err
 		}
 	}
 
 	// Add footer with totals
-	table.Footer(makeRow(r.Total))
+	table.Footer(options.row(r.Total))
 
 	err := table.Render()
 	if err != nil {
@@ -43,15 +146,152 @@ func PrintTable(r Report, w io.Writer, packages bool) error {
 	return nil
 }
 
-// Converts a Summary to a slice of string so that it
-// can be printed in the table
-func makeRow(c Summary) []string {
-	return []string{
-		c.Name,
-		fmt.Sprintf("%d", c.Blocks),
-		fmt.Sprintf("%d", c.MissingBlocks),
-		fmt.Sprintf("%d", c.Stmts),
-		fmt.Sprintf("%d", c.MissingStmts),
-		fmt.Sprintf("%.2f", c.BlockCoverage),
-		fmt.Sprintf("%.2f", c.StmtCoverage)}
+// Formats the columns of a row, highlighting its change if any
+func (o tableOptions) format(s Summary) []string {
+	row := formatRow(s, o.columns)
+	if change, ok := o.changes[s.Name]; ok {
+		for i, c := range o.columns {
+			if c.ID == "name" {
+				row[i] += " (" + change + ")"
+			}
+		}
+	}
+	return row
+}
+
+// Formats a table row, adding the bar chart and the color of its band
+func (o tableOptions) row(s Summary) []string {
+	row := o.format(s)
+	if o.bar != nil {
+		row = append(row, bar(o.bar.value(s), o.barWidth))
+	}
+	if o.bands != nil {
+		color := o.bands.color(s)
+		for i := range row {
+			row[i] = color + row[i] + ansiReset
+		}
+	}
+	return row
+}
+
+// ANSI color of the band a summary belongs to
+func (b ColorBands) color(s Summary) string {
+	column, _ := findColumn(b.Metric)
+	switch coverage := column.value(s); {
+	case coverage < b.Red:
+		return ansiRed
+	case coverage < b.Yellow:
+		return ansiYellow
+	default:
+		return ansiGreen
+	}
+}
+
+// Eighths of a block, used to draw the end of the bars
+var barBlocks = []rune(" ▏▎▍▌▋▊▉█")
+
+// Draws a percentage as a bar of the given width
+func bar(percentage float64, width int) string {
+	if percentage != percentage { // NaN
+		percentage = 0
+	}
+	eighths := int(percentage / 100 * float64(width*8))
+	if eighths > width*8 {
+		eighths = width * 8
+	}
+	var b strings.Builder
+	for i := 0; i < width; i++ {
+		fill := eighths - i*8
+		switch {
+		case fill >= 8:
+			b.WriteRune(barBlocks[8])
+		case fill > 0:
+			b.WriteRune(barBlocks[fill])
+		default:
+			b.WriteRune('░')
+		}
+	}
+	return b.String()
+}
+
+// PrintJSON prints a report, or any other result, as indented JSON
+func PrintJSON(v interface{}, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// PrintFileTests prints the tests that cover each file or location
+func PrintFileTests(files []FileTests, w io.Writer) error {
+	table := tablewriter.NewTable(w,
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleASCII)),
+		tablewriter.WithHeaderAutoFormat(tw.Off),
+	)
+	table.Header("File", "Tests")
+	for _, f := range files {
+		if err := table.Append([]string{f.Name, strings.Join(f.Tests, ", ")}); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+// Page used by PrintHTML
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; }
+td.num, th.num { text-align: right; }
+tfoot td, tr.subtotal td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Coverage report</h1>
+<table>
+<thead><tr>{{range .Headers}}<th{{if .Numeric}} class="num"{{end}}>{{.Value}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr{{if .Subtotal}} class="subtotal"{{end}}>{{range .Cells}}<td{{if .Numeric}} class="num"{{end}}>{{.Value}}</td>{{end}}</tr>
+{{end}}</tbody>
+<tfoot><tr>{{range .Total}}<td{{if .Numeric}} class="num"{{end}}>{{.Value}}</td>{{end}}</tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+// Cell of the HTML table
+type htmlCell struct {
+	Value   string
+	Numeric bool
+}
+
+// Row of the HTML table
+type htmlRow struct {
+	Cells    []htmlCell
+	Subtotal bool
+}
+
+// PrintHTML prints the report as an HTML page
+func PrintHTML(r Report, w io.Writer, packages bool, opts ...TableOption) error {
+	options := newTableOptions(opts)
+	cells := func(values []string) []htmlCell {
+		row := make([]htmlCell, 0, len(values))
+		for i, v := range values {
+			row = append(row, htmlCell{v, options.columns[i].Numeric})
+		}
+		return row
+	}
+	rows := make([]htmlRow, 0, len(r.Files))
+	for _, s := range options.rows(r) {
+		rows = append(rows, htmlRow{cells(options.format(s.Summary)), s.subtotal})
+	}
+	return htmlTemplate.Execute(w, struct {
+		Headers []htmlCell
+		Rows    []htmlRow
+		Total   []htmlCell
+	}{cells(headers(options.columns, packages)), rows, cells(formatRow(r.Total, options.columns))})
 }
@@ -227,28 +227,94 @@ func TestPrintTable_SingleFile(t *testing.T) {
 	assert.GreaterOrEqual(t, totalCount, 2, "Should show coverage values for both file and total")
 }
 
-func TestMakeRow(t *testing.T) {
-	summary := Summary{
-		Name:          "/test.go",
-		Blocks:        50,
-		MissingBlocks: 5,
-		Stmts:         75,
-		MissingStmts:  10,
-		BlockCoverage: 90.00,
-		StmtCoverage:  86.67,
+func TestPrintHTML(t *testing.T) {
+	report := Report{
+		Files: []Summary{{Name: "<main.go>", Blocks: 30, MissingBlocks: 10, BlockCoverage: 66.67}},
+		Total: Summary{Name: "Total", Blocks: 30, MissingBlocks: 10, BlockCoverage: 66.67},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, PrintHTML(report, &buf, true))
+	output := buf.String()
+	assert.Contains(t, output, "<th>Package</th>")
+	assert.Contains(t, output, "<td>&lt;main.go&gt;</td>", "Names are escaped")
+	assert.Contains(t, output, `<td class="num">66.67</td>`)
+	assert.Contains(t, output, "<tfoot><tr><td>Total</td>")
+}
+
+func TestPrintTableWithBarAndColors(t *testing.T) {
+	report := Report{
+		Files: []Summary{
+			{Name: "low.go", Stmts: 10, MissingStmts: 9, StmtCoverage: 10},
+			{Name: "mid.go", Stmts: 10, MissingStmts: 4, StmtCoverage: 60},
+		},
+		Total: Summary{Name: "Total", Stmts: 20, MissingStmts: 13, StmtCoverage: 35},
 	}
+	var buf bytes.Buffer
+	require.NoError(t, PrintTable(report, &buf, false, WithBar("stmt", 4), WithColors(DefaultColorBands)))
+	output := buf.String()
+	assert.Contains(t, output, "| Stmt cover |")
+	assert.Contains(t, output, "\x1b[31mlow.go\x1b[0m")
+	assert.Contains(t, output, "\x1b[33mmid.go\x1b[0m")
+	assert.Contains(t, output, "\x1b[33m██▍░\x1b[0m")
+
+	buf.Reset()
+	require.NoError(t, PrintTable(report, &buf, false, WithBar("name", 4), WithColors(ColorBands{Metric: "xxx"})))
+	assert.NotContains(t, buf.String(), "\x1b[")
+	assert.NotContains(t, buf.String(), "Stmt cover |", "Only coverage metrics can be drawn as bars")
+}
 
-	row := makeRow(summary)
+func TestBar(t *testing.T) {
+	assert.Equal(t, "░░░░", bar(0, 4))
+	assert.Equal(t, "██░░", bar(50, 4))
+	assert.Equal(t, "█▌░░", bar(37.5, 4))
+	assert.Equal(t, "████", bar(100, 4))
+	assert.Equal(t, "████", bar(120, 4))
+	assert.Equal(t, "░░░░", bar(0.0/zero, 4))
+}
 
-	// Verify row has correct number of columns
-	assert.Equal(t, 7, len(row), "Row should have 7 columns")
+func TestColorBands(t *testing.T) {
+	bands := ColorBands{Metric: "block", Red: 30, Yellow: 90}
+	assert.Equal(t, ansiRed, bands.color(Summary{BlockCoverage: 29.9}))
+	assert.Equal(t, ansiYellow, bands.color(Summary{BlockCoverage: 30}))
+	assert.Equal(t, ansiGreen, bands.color(Summary{BlockCoverage: 90}))
+}
 
-	// Verify row contents
-	assert.Equal(t, "/test.go", row[0], "First column should be name")
-	assert.Equal(t, "50", row[1], "Second column should be blocks")
-	assert.Equal(t, "5", row[2], "Third column should be missing blocks")
-	assert.Equal(t, "75", row[3], "Fourth column should be stmts")
-	assert.Equal(t, "10", row[4], "Fifth column should be missing stmts")
-	assert.Equal(t, "90.00", row[5], "Sixth column should be block coverage")
-	assert.Equal(t, "86.67", row[6], "Seventh column should be stmt coverage")
+var zero float64
+
+func TestPrintGroups(t *testing.T) {
+	report := Report{
+		Files: []Summary{
+			{Name: "/a/x.go", Stmts: 10, MissingStmts: 5, StmtCoverage: 50},
+			{Name: "/b/y.go", Stmts: 10, MissingStmts: 0, StmtCoverage: 100},
+		},
+		Total: Summary{Name: "Total", Stmts: 20, MissingStmts: 5, StmtCoverage: 75},
+	}
+	grouped, err := GroupByPackage(report, "filename", "asc")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, PrintTable(report, &buf, false, WithGroups(grouped.Groups)))
+	output := buf.String()
+	assert.Contains(t, output, "Subtotal /a")
+	assert.Less(t, strings.Index(output, "/a/x.go"), strings.Index(output, "Subtotal /a"))
+	assert.Less(t, strings.Index(output, "Subtotal /a"), strings.Index(output, "/b/y.go"))
+
+	buf.Reset()
+	require.NoError(t, PrintHTML(report, &buf, false, WithGroups(grouped.Groups)))
+	assert.Contains(t, buf.String(), `<tr class="subtotal"><td>Subtotal /b</td>`)
+}
+
+func TestPrintTableWithChanges(t *testing.T) {
+	report := Report{
+		Files: []Summary{{Name: "a.go", Stmts: 10, StmtCoverage: 100}, {Name: "b.go", Stmts: 10, StmtCoverage: 50}},
+		Total: Summary{Name: "Total", Stmts: 20, StmtCoverage: 75},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, PrintTable(report, &buf, false, WithChanges(map[string]string{"b.go": "stmt -2.50"})))
+	assert.Contains(t, buf.String(), "| b.go (stmt -2.50) |")
+	assert.Contains(t, buf.String(), "| a.go              |")
+	assert.Equal(t, "b.go", report.Files[1].Name)
+
+	buf.Reset()
+	require.NoError(t, PrintHTML(report, &buf, false, WithChanges(map[string]string{"b.go": "new"})))
+	assert.Contains(t, buf.String(), "<td>b.go (new)</td>")
 }
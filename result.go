@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/mcubik/goverreport/report"
+)
+
+// Exit codes
+const (
+	exitOK             = 0
+	exitThreshold      = 1 // The coverage is below the threshold
+	exitError          = 2 // Invalid arguments, or any other error
+	exitPolicy         = 3 // Some rules of the coverage policy failed
+	exitUnreadable     = 4 // The coverprofile can't be read
+	exitInvalidProfile = 5 // The coverprofile can't be parsed
+	exitSources        = 6 // The coverprofile doesn't match the source files
+	exitConfig         = 7 // The configuration can't be loaded or is invalid
+	exitBaseline       = 8 // The coverage regressed from a baseline, reserved until baselines are compared
+)
+
+// The configuration can't be loaded or is invalid
+type configFailure struct {
+	err error
+}
+
+func (e *configFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *configFailure) Unwrap() error {
+	return e.err
+}
+
+// Outcome of a run, written with -result
+type gateResult struct {
+	Passed   bool              `json:"passed"`
+	ExitCode int               `json:"exitCode"`
+	Error    string            `json:"error,omitempty"`
+	Checks   []thresholdResult `json:"checks"`
+}
+
+// Exit code for the results and error of a run
+func exitCode(results checkResults, err error) int {
+	var policyErr *policyFailure
+	var sourcesErr *sourcesFailure
+	var configErr *configFailure
+	var profileErr *report.ProfileError
+	var pathErr *fs.PathError
+	switch {
+	case err == nil && results.passed():
+		return exitOK
+	case err == nil:
+		return exitThreshold
+	case errors.As(err, &policyErr):
+		return exitPolicy
+	case errors.As(err, &sourcesErr):
+		return exitSources
+	case errors.As(err, &configErr):
+		return exitConfig
+	case errors.As(err, &profileErr) && errors.As(err, &pathErr):
+		return exitUnreadable
+	case errors.As(err, &profileErr):
+		return exitInvalidProfile
+	default:
+		return exitError
+	}
+}
+
+// Writes the outcome of a run as a JSON file
+func writeResult(filename string, results checkResults, err error, code int) error {
+	result := gateResult{Passed: code == exitOK, ExitCode: code, Checks: results}
+	if result.Checks == nil {
+		result.Checks = []thresholdResult{}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0600)
+}
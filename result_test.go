@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	assert := assert.New(t)
+	passed := checkResults{{Rule: "threshold", Passed: true}}
+	failed := checkResults{{Rule: "threshold"}}
+	assert.Equal(exitOK, exitCode(nil, nil))
+	assert.Equal(exitOK, exitCode(passed, nil))
+	assert.Equal(exitThreshold, exitCode(failed, nil))
+	assert.Equal(exitPolicy, exitCode(passed, &policyFailure{1}))
+	assert.Equal(exitError, exitCode(nil, errors.New("Invalid format 'xxx'")))
+	_, _, err := resolveConfig("testdata/xxx.yml", "", noEnv)
+	require.Error(t, err)
+	assert.Equal(exitConfig, exitCode(nil, &configFailure{err}))
+	assert.Equal(err.Error(), (&configFailure{err}).Error())
+
+	buf := bytes.Buffer{}
+	_, err = run(configuration{}, arguments{coverprofile: "xxx.out"}, &buf)
+	assert.Equal(exitUnreadable, exitCode(nil, err))
+	_, err = run(configuration{}, arguments{coverprofile: "report/testdata/badtests/TestBroken.out"}, &buf)
+	assert.Equal(exitInvalidProfile, exitCode(nil, err))
+}
+
+func TestWriteResult(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), "result.json")
+	results := checkResults{{Rule: "threshold", Name: "Total", Metric: "block", Op: ">=", Threshold: 80, Value: 79.9}}
+	require.NoError(t, writeResult(file, results, nil, exitThreshold))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var result gateResult
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(gateResult{ExitCode: exitThreshold, Checks: results}, result)
+
+	require.NoError(t, writeResult(file, nil, errors.New("Invalid format 'xxx'"), exitError))
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(`{"passed": false, "exitCode": 2, "error": "Invalid format 'xxx'", "checks": []}`, string(data))
+
+	assert.Error(writeResult(filepath.Join(file, "xxx"), nil, nil, exitOK))
+}
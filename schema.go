@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// File with the JSON Schema of the configuration, kept up to date by the tests
+const schemaFile = "goverreport.schema.json"
+
+// Prints the JSON Schema of the configuration file, generated from the
+// configuration struct. Constraints on the values come from schema tags,
+// e.g. `schema:"minimum=0,maximum=100"` or `schema:"enum=block|stmt"`.
+func printSchema(w io.Writer) error {
+	schema := typeSchema(reflect.TypeOf(configDocument{}), "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "goverreport configuration"
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Schema of a type, with the constraints of a schema tag
+func typeSchema(t reflect.Type, tag string) map[string]interface{} {
+	schema := make(map[string]interface{})
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), tag)
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int:
+		schema["type"] = "integer"
+	case reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), tag)
+		return schema
+	case reflect.Map:
+		schema["type"] = "object"
+		if t.Elem() == reflect.TypeOf(configuration{}) {
+			// Profiles have the keys of the configuration, but can't be nested
+			schema["additionalProperties"] = structSchema(t.Elem(), "profiles")
+		} else {
+			schema["additionalProperties"] = typeSchema(t.Elem(), tag)
+		}
+		return schema
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	for _, constraint := range strings.Split(tag, ",") {
+		name, value, found := strings.Cut(constraint, "=")
+		if !found {
+			continue
+		}
+		if name == "enum" {
+			schema[name] = strings.Split(value, "|")
+		} else if number, err := strconv.ParseFloat(value, 64); err == nil {
+			schema[name] = number
+		}
+	}
+	return schema
+}
+
+// Schema of a struct without the given yaml keys
+func structSchema(t reflect.Type, omit ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           structProperties(t, omit),
+		"additionalProperties": false}
+}
+
+// Schemas of the fields of a struct by their yaml keys, including the
+// ones of inlined structs, leaving out the omitted keys
+func structProperties(t reflect.Type, omit []string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		options := strings.Split(field.Tag.Get("yaml"), ",")
+		if len(options) > 1 && options[1] == "inline" {
+			for key, value := range structProperties(field.Type, omit) {
+				properties[key] = value
+			}
+			continue
+		}
+		if oneOf(options[0], omit) {
+			continue
+		}
+		properties[options[0]] = typeSchema(field.Type, field.Tag.Get("schema"))
+	}
+	return properties
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.NoError(t, printSchema(&buf))
+	var schema struct {
+		Properties map[string]struct {
+			Type    string   `json:"type"`
+			Enum    []string `json:"enum"`
+			Minimum *float64 `json:"minimum"`
+			Maximum *float64 `json:"maximum"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &schema))
+	assert.Len(t, schema.Properties, len(configKeys())+1)
+	assert.Equal(t, "string", schema.Properties["extends"].Type)
+	assert.Equal(t, []string{"block", "stmt"}, schema.Properties["thresholdType"].Enum)
+	assert.Equal(t, 100.0, *schema.Properties["threshold"].Maximum)
+	assert.Equal(t, "array", schema.Properties["policy"].Type)
+
+	var profiles struct {
+		Properties struct {
+			Profiles struct {
+				AdditionalProperties struct {
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"additionalProperties"`
+			} `json:"profiles"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &profiles))
+	profile := profiles.Properties.Profiles.AdditionalProperties.Properties
+	assert.Contains(t, profile, "threshold")
+	assert.NotContains(t, profile, "profiles", "Profiles can't be nested")
+	assert.NotContains(t, profile, "extends")
+}
+
+func TestSchemaFileUpToDate(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.NoError(t, printSchema(&buf))
+	data, err := os.ReadFile(schemaFile)
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), string(data), "Run 'go run . schema > "+schemaFile+"'")
+}
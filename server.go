@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcubik/goverreport/report"
+)
+
+// Maximum size of an uploaded coverprofile
+const maxUploadSize = 64 << 20
+
+// Serves the report over HTTP, reloading it whenever the coverprofile changes
+type server struct {
+	config configuration
+	args   arguments
+
+	mu      sync.Mutex
+	modTime time.Time
+	rep     report.Report
+	blocks  map[string]*report.FileBlocks
+}
+
+// Starts the HTTP server and blocks until it fails
+func runServe(config configuration, args arguments) error {
+	srv := &http.Server{
+		Addr:              args.addr,
+		Handler:           newServer(config, args).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", args.coverprofile, args.addr)
+	return srv.ListenAndServe()
+}
+
+func newServer(config configuration, args arguments) *server {
+	return &server{config: config, args: args}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/api/report", s.handleReport)
+	mux.HandleFunc("/api/files/", s.handleFile)
+	mux.HandleFunc("/api/upload", s.handleUpload)
+	return mux
+}
+
+// Returns the current report, generating it again if the coverprofile
+// changed since it was last loaded
+func (s *server) current() (report.Report, map[string]*report.FileBlocks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.args.coverprofile)
+	if err != nil {
+		return report.Report{}, nil, err
+	}
+	if !info.ModTime().Equal(s.modTime) || s.blocks == nil {
+		rep, blocks, err := generateReport(s.config, s.args)
+		if err != nil {
+			return report.Report{}, nil, err
+		}
+		s.rep, s.blocks, s.modTime = rep, blocks, info.ModTime()
+	}
+	return s.rep, s.blocks, nil
+}
+
+// Serves the HTML report
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	rep, _, err := s.current()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	columns, err := tableColumns(s.config, s.args)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = report.PrintHTML(rep, w, s.args.packages, report.WithColumns(columns))
+}
+
+// Serves the whole report as JSON
+func (s *server) handleReport(w http.ResponseWriter, r *http.Request) {
+	rep, _, err := s.current()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = report.PrintJSON(rep, w)
+}
+
+// Coverage block of a file in the JSON API
+type apiBlock struct {
+	StartLine int `json:"startLine"`
+	StartCol  int `json:"startCol"`
+	EndLine   int `json:"endLine"`
+	EndCol    int `json:"endCol"`
+	NumStmt   int `json:"numStmt"`
+	Count     int `json:"count"`
+}
+
+// Serves the summary and blocks of a single file as JSON. The file can be
+// given by its name in the report or by a path suffix.
+func (s *server) handleFile(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/files/")
+	rep, blocks, err := s.current()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, summary := range rep.Files {
+		if summary.Name != name && !strings.HasSuffix(summary.Name, "/"+name) {
+			continue
+		}
+		fileBlocks := []apiBlock{}
+		if b, ok := blocks[summary.Name]; ok {
+			for _, block := range b.Blocks {
+				fileBlocks = append(fileBlocks, apiBlock(block))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = report.PrintJSON(struct {
+			report.Summary
+			ProfileBlocks []apiBlock `json:"profileBlocks"`
+		}{summary, fileBlocks}, w)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// Replaces the coverprofile with the one in the request body, in any of the
+// input formats. The profile is validated before replacing the current one.
+// Uploads are only accepted with the token of the server, and are disabled
+// if it has none.
+func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Use POST to upload a coverprofile", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.args.token == "" {
+		http.Error(w, "Uploads are disabled, start the server with -token to enable them", http.StatusForbidden)
+		return
+	}
+	expected := []byte("Bearer " + s.args.token)
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.args.coverprofile), ".upload-*.out")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, http.MaxBytesReader(w, r.Body, maxUploadSize))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := report.ReadProfiles(tmp.Name()); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid coverprofile: '%s'", err), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	err = os.Rename(tmp.Name(), s.args.coverprofile)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a server for a copy of the sample coverprofile
+func sampleServer(t *testing.T, token string) (*server, string) {
+	sample, err := os.ReadFile("sample_coverage.out")
+	require.NoError(t, err)
+	profile := filepath.Join(t.TempDir(), "coverage.out")
+	require.NoError(t, os.WriteFile(profile, sample, 0600))
+	args := arguments{coverprofile: profile, sortBy: "filename", order: "asc", token: token}
+	return newServer(configuration{Root: "github.com/mcubik/goverreport"}, args), profile
+}
+
+func request(s *server, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if len(header) == 2 {
+		req.Header.Set(header[0], header[1])
+	}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeHTML(t *testing.T) {
+	s, _ := sampleServer(t, "")
+	rec := request(s, http.MethodGet, "/", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<td>/report/report.go</td>")
+	assert.Equal(t, http.StatusNotFound, request(s, http.MethodGet, "/xxx", "").Code)
+}
+
+func TestServeReport(t *testing.T) {
+	s, _ := sampleServer(t, "")
+	rec := request(s, http.MethodGet, "/api/report", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"blocks": 81`)
+}
+
+func TestServeFile(t *testing.T) {
+	s, _ := sampleServer(t, "")
+	rec := request(s, http.MethodGet, "/api/files/report/view.go", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name": "/report/view.go"`)
+	assert.Contains(t, rec.Body.String(), `"startLine": 10`)
+	assert.Contains(t, rec.Body.String(), `"blocks": 4,`)
+	assert.Equal(t, http.StatusNotFound, request(s, http.MethodGet, "/api/files/xxx.go", "").Code)
+}
+
+func TestServeMissingProfile(t *testing.T) {
+	s, profile := sampleServer(t, "")
+	require.NoError(t, os.Remove(profile))
+	assert.Equal(t, http.StatusInternalServerError, request(s, http.MethodGet, "/", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, request(s, http.MethodGet, "/api/report", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, request(s, http.MethodGet, "/api/files/main.go", "").Code)
+}
+
+func TestServeUpload(t *testing.T) {
+	s, _ := sampleServer(t, "secret")
+	profile := "mode: set\ngithub.com/mcubik/goverreport/main.go:1.1,2.2 4 1\n"
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request(s, http.MethodGet, "/api/upload", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(s, http.MethodPost, "/api/upload", profile).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(s, http.MethodPost, "/api/upload", profile, "Authorization", "Bearer secre").Code)
+	rec := request(s, http.MethodPost, "/api/upload", "xxx", "Authorization", "Bearer secret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid coverprofile")
+
+	// The original report is still served
+	assert.Contains(t, request(s, http.MethodGet, "/api/report", "").Body.String(), `"blocks": 81`)
+
+	rec = request(s, http.MethodPost, "/api/upload", profile, "Authorization", "Bearer secret")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Contains(t, request(s, http.MethodGet, "/api/report", "").Body.String(), `"stmts": 4`)
+
+	lcov := "SF:github.com/mcubik/goverreport/main.go\nDA:1,1\nDA:2,0\nDA:3,0\nend_of_record\n"
+	rec = request(s, http.MethodPost, "/api/upload", lcov, "Authorization", "Bearer secret")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "Any input format is accepted")
+	assert.Contains(t, request(s, http.MethodGet, "/api/report", "").Body.String(), `"stmts": 3`)
+}
+
+func TestServeUploadWithoutToken(t *testing.T) {
+	s, _ := sampleServer(t, "")
+	rec := request(s, http.MethodPost, "/api/upload", "mode: set\n")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Uploads are disabled")
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Run shows the browser in the terminal until the user quits
+func Run(m *Model, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	// Use the alternate screen and hide the cursor while browsing
+	_, _ = io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer func() { _, _ = io.WriteString(out, "\x1b[?25h\x1b[?1049l") }()
+
+	reader := bufio.NewReader(in)
+	for {
+		_, height, err := term.GetSize(fd)
+		if err != nil {
+			height = 24
+		}
+		_, _ = io.WriteString(out, "\x1b[H\x1b[2J")
+		m.Render(out, height)
+		key, err := readKey(reader)
+		if err != nil {
+			return err
+		}
+		if !m.Update(key) {
+			return nil
+		}
+	}
+}
+
+// Reads a key press, translating control characters and escape sequences
+// to key names
+func readKey(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl+c", nil
+	case 8, 127:
+		return "backspace", nil
+	case 27:
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		if next, _ := r.ReadByte(); next != '[' {
+			return "esc", nil
+		}
+		code, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch code {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		case 'C':
+			return "right", nil
+		case 'D':
+			return "left", nil
+		case '5', '6':
+			// Page up and down end with a tilde
+			if _, err := r.ReadByte(); err != nil {
+				return "", err
+			}
+			if code == '5' {
+				return "pgup", nil
+			}
+			return "pgdown", nil
+		}
+		return "esc", nil
+	}
+	return string(c), nil
+}
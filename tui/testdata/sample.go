@@ -0,0 +1,13 @@
+package sample
+
+func Covered() int {
+	return 1
+}
+
+func Uncovered() int {
+	return 2
+}
+
+func AlsoUncovered() int {
+	return 3
+}
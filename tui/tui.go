@@ -0,0 +1,400 @@
+// Package tui implements an interactive terminal browser for coverage reports
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mcubik/goverreport/report"
+)
+
+// Screens of the browser, from the most general to the most detailed
+type level int
+
+const (
+	packagesLevel level = iota
+	filesLevel
+	sourceLevel
+)
+
+// Sort columns, selected with the number keys in this order
+var sortKeys = report.SortKeys()
+
+// Coverage state of a source line
+type lineState int
+
+const (
+	notTracked lineState = iota
+	covered
+	uncovered
+)
+
+// Model holds the state of the browser. It is updated with key presses
+// and rendered as text, so that it doesn't depend on a real terminal.
+type Model struct {
+	rep    report.Report
+	blocks map[string]*report.FileBlocks
+
+	level         level
+	sortBy, order string
+	filter        string
+	editing       bool // Whether the filter is being typed
+	pkg, file     string
+	cursor        int
+	lines         []string
+	states        []lineState
+	message       string
+}
+
+// Creates a browser for a file report. blocks are the coverage blocks of
+// each file, used to show its source.
+func NewModel(rep report.Report, blocks map[string]*report.FileBlocks, sortBy, order string) *Model {
+	return &Model{rep: rep, blocks: blocks, sortBy: sortBy, order: order}
+}
+
+// Summaries shown in the current screen, filtered and sorted
+func (m *Model) rows() []report.Summary {
+	var rows []report.Summary
+	switch m.level {
+	case packagesLevel:
+		rows = m.packages()
+	case filesLevel:
+		for _, f := range m.rep.Files {
+			if filepath.Dir(f.Name) == m.pkg {
+				rows = append(rows, f)
+			}
+		}
+	default:
+		return nil
+	}
+	filtered := rows[:0]
+	for _, r := range rows {
+		if strings.Contains(r.Name, m.filter) {
+			filtered = append(filtered, r)
+		}
+	}
+	if err := report.SortSummaries(filtered, m.sortBy, m.order); err != nil {
+		m.message = err.Error()
+	}
+	return filtered
+}
+
+// Aggregates the files of the report by package
+func (m *Model) packages() []report.Summary {
+	byPackage := make(map[string][]report.Summary)
+	var names []string
+	for _, f := range m.rep.Files {
+		pkg := filepath.Dir(f.Name)
+		if _, ok := byPackage[pkg]; !ok {
+			names = append(names, pkg)
+		}
+		byPackage[pkg] = append(byPackage[pkg], f)
+	}
+	packages := make([]report.Summary, 0, len(names))
+	for _, name := range names {
+		packages = append(packages, report.Aggregate(name, byPackage[name]))
+	}
+	return packages
+}
+
+// Update applies a key press to the model and returns false when the
+// browser must be closed
+func (m *Model) Update(key string) bool {
+	m.message = ""
+	if m.editing {
+		m.editFilter(key)
+		return true
+	}
+	switch key {
+	case "q", "ctrl+c":
+		return false
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-10)
+	case "pgdown":
+		m.move(10)
+	case "enter", "right", "l":
+		m.open()
+	case "left", "h", "backspace", "esc":
+		m.back()
+	case "/":
+		if m.level != sourceLevel {
+			m.editing = true
+		}
+	case "r":
+		if m.order == "asc" {
+			m.order = "desc"
+		} else {
+			m.order = "asc"
+		}
+	case "n":
+		m.nextUncovered(1)
+	case "N", "p":
+		m.nextUncovered(-1)
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(sortKeys) {
+			m.sortBy = sortKeys[key[0]-'1']
+		}
+	}
+	return true
+}
+
+// Handles the keys typed while editing the filter
+func (m *Model) editFilter(key string) {
+	switch key {
+	case "enter":
+		m.editing = false
+	case "esc", "ctrl+c":
+		m.editing = false
+		m.filter = ""
+	case "backspace":
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+		}
+	default:
+		if len(key) == 1 {
+			m.filter += key
+		}
+	}
+	m.cursor = 0
+}
+
+// Moves the cursor, keeping it within the current screen
+func (m *Model) move(delta int) {
+	size := len(m.rows())
+	if m.level == sourceLevel {
+		size = len(m.lines)
+	}
+	m.cursor += delta
+	if m.cursor >= size {
+		m.cursor = size - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// Opens the selected package or file
+func (m *Model) open() {
+	rows := m.rows()
+	if m.level == sourceLevel || m.cursor >= len(rows) {
+		return
+	}
+	name := rows[m.cursor].Name
+	if m.level == packagesLevel {
+		m.level, m.pkg, m.cursor, m.filter = filesLevel, name, 0, ""
+		return
+	}
+	if err := m.loadSource(name); err != nil {
+		m.message = err.Error()
+		return
+	}
+	m.level, m.file, m.cursor = sourceLevel, name, 0
+}
+
+// Goes back to the previous screen, reselecting the item that was open
+func (m *Model) back() {
+	switch m.level {
+	case sourceLevel:
+		m.level = filesLevel
+		m.cursor = m.indexOf(m.file)
+	case filesLevel:
+		m.level, m.filter = packagesLevel, ""
+		m.cursor = m.indexOf(m.pkg)
+	}
+}
+
+func (m *Model) indexOf(name string) int {
+	for i, r := range m.rows() {
+		if r.Name == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// Reads the source of a file and marks which lines are covered
+func (m *Model) loadSource(name string) error {
+	fileBlocks, ok := m.blocks[name]
+	if !ok || fileBlocks.Path == "" {
+		return fmt.Errorf("Source not found for %s", name)
+	}
+	// #nosec G304 -- reads a source file listed in the coverage profile
+	file, err := os.Open(fileBlocks.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	states := make([]lineState, len(lines))
+	for _, block := range fileBlocks.Blocks {
+		for line := block.StartLine; line <= block.EndLine && line <= len(lines); line++ {
+			if block.Count == 0 {
+				states[line-1] = uncovered
+			} else if states[line-1] == notTracked {
+				states[line-1] = covered
+			}
+		}
+	}
+	m.lines, m.states = lines, states
+	return nil
+}
+
+// Moves to the start of the next (or previous) uncovered block. In the
+// files screen, opens the selected file at its first uncovered block.
+func (m *Model) nextUncovered(direction int) {
+	if m.level == packagesLevel {
+		return
+	}
+	from := m.cursor
+	if m.level == filesLevel {
+		m.open()
+		if m.level != sourceLevel {
+			return
+		}
+		from = -1
+	}
+	var starts []int
+	for _, block := range m.blocks[m.file].Blocks {
+		if block.Count == 0 {
+			starts = append(starts, block.StartLine-1)
+		}
+	}
+	sort.Ints(starts)
+	if direction < 0 {
+		for i := len(starts) - 1; i >= 0; i-- {
+			if starts[i] < from {
+				m.cursor = starts[i]
+				return
+			}
+		}
+	} else {
+		for _, start := range starts {
+			if start > from {
+				m.cursor = start
+				return
+			}
+		}
+	}
+	m.message = "No more uncovered blocks"
+}
+
+// Render writes the current screen, fitting it in the given number of lines
+func (m *Model) Render(w io.Writer, height int) {
+	var b strings.Builder
+	b.WriteString(m.title() + "\n")
+	body := height - 3
+	if body < 1 {
+		body = 1
+	}
+	if m.level == sourceLevel {
+		m.renderSource(&b, body)
+	} else {
+		m.renderTable(&b, body)
+	}
+	b.WriteString(m.footer())
+	fmt.Fprint(w, strings.ReplaceAll(b.String(), "\n", "\r\n"))
+}
+
+// Breadcrumbs and sorting of the current screen
+func (m *Model) title() string {
+	path := "Packages"
+	switch m.level {
+	case filesLevel:
+		path += " > " + m.pkg
+	case sourceLevel:
+		path += " > " + m.pkg + " > " + filepath.Base(m.file)
+	}
+	return fmt.Sprintf("\x1b[1m%s\x1b[0m  (sort: %s %s)", path, m.sortBy, m.order)
+}
+
+func (m *Model) footer() string {
+	switch {
+	case m.editing:
+		return "Filter: " + m.filter + "_"
+	case m.message != "":
+		return m.message
+	case m.filter != "":
+		return "Filter: " + m.filter
+	case m.level == sourceLevel:
+		return "↑↓ move  n/N next/previous uncovered block  ← back  q quit"
+	}
+	return fmt.Sprintf("↑↓ move  → open  ← back  1-%d sort  r reverse  / filter  n uncovered  q quit", len(sortKeys))
+}
+
+func (m *Model) renderTable(b *strings.Builder, height int) {
+	rows := m.rows()
+	item := "Package"
+	if m.level == filesLevel {
+		item = "File"
+	}
+	width := len(item)
+	for _, r := range rows {
+		if len(r.Name) > width {
+			width = len(r.Name)
+		}
+	}
+	format := fmt.Sprintf("  %%-%ds %%8s %%8s %%8s %%8s %%8s %%8s", width)
+	fmt.Fprintf(b, format+"\n", item, "Blocks", "Missing", "Stmts", "Missing", "Block %", "Stmt %")
+	start := scroll(m.cursor, len(rows), height-1)
+	for i := start; i < len(rows) && i < start+height-1; i++ {
+		r := rows[i]
+		line := fmt.Sprintf(format, r.Name,
+			fmt.Sprint(r.Blocks), fmt.Sprint(r.MissingBlocks),
+			fmt.Sprint(r.Stmts), fmt.Sprint(r.MissingStmts),
+			fmt.Sprintf("%.2f", r.BlockCoverage), fmt.Sprintf("%.2f", r.StmtCoverage))
+		if i == m.cursor {
+			line = "\x1b[7m>" + line[1:] + "\x1b[0m"
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+func (m *Model) renderSource(b *strings.Builder, height int) {
+	start := scroll(m.cursor, len(m.lines), height)
+	for i := start; i < len(m.lines) && i < start+height; i++ {
+		marker := " "
+		color := ""
+		switch m.states[i] {
+		case covered:
+			color = "\x1b[32m"
+		case uncovered:
+			marker, color = "!", "\x1b[31m"
+		}
+		if i == m.cursor {
+			color += "\x1b[7m"
+		}
+		line := strings.ReplaceAll(m.lines[i], "\t", "    ")
+		fmt.Fprintf(b, "%s%5d %s %s\x1b[0m\n", color, i+1, marker, line)
+	}
+}
+
+// Returns the first row to show so that the cursor is visible, centering it
+func scroll(cursor, size, height int) int {
+	if size <= height {
+		return 0
+	}
+	start := cursor - height/2
+	if start < 0 {
+		start = 0
+	}
+	if start > size-height {
+		start = size - height
+	}
+	return start
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mcubik/goverreport/report"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func sampleModel() *Model {
+	rep := report.Report{
+		Files: []report.Summary{
+			{Name: "pkg/a.go", Blocks: 10, MissingBlocks: 5, Stmts: 10, MissingStmts: 5, BlockCoverage: 50, StmtCoverage: 50},
+			{Name: "pkg/b.go", Blocks: 10, MissingBlocks: 0, Stmts: 10, MissingStmts: 0, BlockCoverage: 100, StmtCoverage: 100},
+			{Name: "other/c.go", Blocks: 4, MissingBlocks: 4, Stmts: 4, MissingStmts: 4, BlockCoverage: 0, StmtCoverage: 0},
+		},
+	}
+	blocks := map[string]*report.FileBlocks{
+		"pkg/a.go": {Name: "pkg/a.go", Path: "testdata/sample.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, EndLine: 5, NumStmt: 1, Count: 1},
+			{StartLine: 7, EndLine: 9, NumStmt: 1, Count: 0},
+			{StartLine: 11, EndLine: 13, NumStmt: 1, Count: 0},
+		}},
+		"pkg/b.go": {Name: "pkg/b.go"},
+	}
+	return NewModel(rep, blocks, "filename", "asc")
+}
+
+func names(rows []report.Summary) []string {
+	var result []string
+	for _, r := range rows {
+		result = append(result, r.Name)
+	}
+	return result
+}
+
+func TestPackages(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	rows := m.rows()
+	assert.Equal([]string{"other", "pkg"}, names(rows))
+	assert.Equal(20, rows[1].Blocks)
+	assert.Equal(5, rows[1].MissingBlocks)
+	assert.InDelta(75, rows[1].BlockCoverage, 0.01)
+}
+
+func TestSortAndReverse(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	m.Update("3")
+	assert.Equal("block", m.sortBy)
+	assert.Equal([]string{"other", "pkg"}, names(m.rows()))
+	m.Update("6")
+	assert.Equal("stmts", m.sortBy, "Every sort key of the report can be selected")
+	m.Update("9")
+	assert.Equal("stmts", m.sortBy)
+	m.Update("3")
+	m.Update("r")
+	assert.Equal([]string{"pkg", "other"}, names(m.rows()))
+	m.Update("r")
+	assert.Equal("asc", m.order)
+}
+
+func TestNavigation(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	m.Update("down")
+	m.Update("down")
+	assert.Equal(1, m.cursor, "Cursor stays in the table")
+	m.Update("enter")
+	assert.Equal(filesLevel, m.level)
+	assert.Equal("pkg", m.pkg)
+	assert.Equal([]string{"pkg/a.go", "pkg/b.go"}, names(m.rows()))
+
+	m.Update("enter")
+	assert.Equal(sourceLevel, m.level)
+	assert.Equal("pkg/a.go", m.file)
+	assert.Equal(uncovered, m.states[7])
+	assert.Equal(covered, m.states[3])
+	assert.Equal(notTracked, m.states[0])
+
+	m.Update("left")
+	assert.Equal(filesLevel, m.level)
+	assert.Equal(0, m.cursor)
+	m.Update("down")
+	m.Update("enter")
+	assert.Equal(filesLevel, m.level, "Source of b.go not found")
+	assert.Contains(m.message, "Source not found")
+
+	m.Update("esc")
+	assert.Equal(packagesLevel, m.level)
+	assert.Equal(1, m.cursor, "Package is selected again")
+	assert.False(m.Update("q"))
+}
+
+func TestJumpToUncovered(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	m.Update("down")
+	m.Update("right")
+	m.Update("n")
+	assert.Equal(sourceLevel, m.level)
+	assert.Equal(6, m.cursor)
+	m.Update("n")
+	assert.Equal(10, m.cursor)
+	m.Update("n")
+	assert.Equal(10, m.cursor)
+	assert.Equal("No more uncovered blocks", m.message)
+	m.Update("N")
+	assert.Equal(6, m.cursor)
+}
+
+func TestFilter(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	for _, key := range []string{"/", "p", "k", "x", "backspace", "enter"} {
+		m.Update(key)
+	}
+	assert.Equal("pk", m.filter)
+	assert.Equal([]string{"pkg"}, names(m.rows()))
+	m.Update("/")
+	m.Update("esc")
+	assert.Equal("", m.filter)
+	assert.Len(m.rows(), 2)
+}
+
+func TestRender(t *testing.T) {
+	assert := assert.New(t)
+	m := sampleModel()
+	buf := bytes.Buffer{}
+	m.Render(&buf, 10)
+	assert.Contains(buf.String(), "Packages")
+	assert.Contains(buf.String(), "Block %")
+	assert.Contains(buf.String(), "75.00")
+
+	m.Update("down")
+	m.Update("right")
+	m.Update("right")
+	buf.Reset()
+	m.Render(&buf, 6)
+	assert.Contains(buf.String(), "Packages > pkg > a.go")
+	assert.Contains(buf.String(), "func Covered")
+	assert.NotContains(buf.String(), "AlsoUncovered", "Only fits a few lines")
+}
+
+func TestScroll(t *testing.T) {
+	assert.Equal(t, 0, scroll(5, 10, 20))
+	assert.Equal(t, 0, scroll(2, 100, 10))
+	assert.Equal(t, 45, scroll(50, 100, 10))
+	assert.Equal(t, 90, scroll(99, 100, 10))
+}
+
+func TestReadKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("\x1b[A\x1b[B\x1b[C\x1b[D\x1b[5~\x1b[6~\rq\x7f\x03"))
+	var keys []string
+	for {
+		key, err := readKey(reader)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"up", "down", "right", "left", "pgup", "pgdown", "enter", "q", "backspace", "ctrl+c"}, keys)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mcubik/goverreport/report"
+	"golang.org/x/term"
+)
+
+// Time to wait for more changes before rendering, so that a burst of
+// writes produces a single report
+const watchDebounce = 200 * time.Millisecond
+
+// Renders the report every time the coverprofile changes, highlighting the
+// files whose coverage changed since the previous run. If a command is
+// configured, changes to the Go sources re-run it; it is expected to
+// regenerate the coverprofile (e.g. "go test -coverprofile=coverage.out ./...").
+// Runs until stop is closed.
+func runWatch(config configuration, args arguments, writer io.Writer, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	profile, err := filepath.Abs(args.coverprofile)
+	if err != nil {
+		return err
+	}
+	// Watch the directory, as the profile may be replaced rather than written
+	if err := watcher.Add(filepath.Dir(profile)); err != nil {
+		return err
+	}
+	// The command of the configuration is already in the arguments
+	command := args.watchCommand
+	if command != "" && strings.TrimSpace(command) == "" {
+		return fmt.Errorf("Invalid watch command '%s', it can't be blank", command)
+	}
+	if command != "" {
+		// Watch the whole module, even when run from one of its packages
+		dir := args.sourceDir
+		if dir == "" {
+			dir = "."
+		}
+		if err := watchSources(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	clearScreen := writer == io.Writer(os.Stdout) && term.IsTerminal(int(os.Stdout.Fd()))
+	previous, _ := renderWatch(config, args, writer, nil, clearScreen)
+
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	var profileChanged, sourcesChanged bool
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-watcher.Errors:
+			return err
+		case event := <-watcher.Events:
+			name, _ := filepath.Abs(event.Name)
+			switch {
+			case name == profile && event.Has(fsnotify.Write|fsnotify.Create):
+				profileChanged = true
+			case command != "" && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"):
+				sourcesChanged = true
+			case command != "" && event.Has(fsnotify.Create):
+				// Watch new directories
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					_ = watchSources(watcher, name)
+				}
+				continue
+			default:
+				continue
+			}
+			debounce.Reset(watchDebounce)
+		case <-debounce.C:
+			if sourcesChanged {
+				sourcesChanged = false
+				runWatchCommand(command, writer)
+			}
+			if profileChanged {
+				profileChanged = false
+				if rep, err := renderWatch(config, args, writer, previous, clearScreen); err == nil {
+					previous = rep
+				}
+			}
+		}
+	}
+}
+
+// Adds a directory and its subdirectories to the watcher, skipping hidden
+// directories, vendor and testdata
+func watchSources(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// Runs the configured test command with the shell, so that it can quote
+// its arguments, showing its output
+func runWatchCommand(command string, writer io.Writer) {
+	fmt.Fprintf(writer, "Running %s\n", command)
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	// #nosec G204 -- the command is configured by the user
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintln(writer, err)
+	}
+}
+
+// Renders the report, highlighting the changes since a previous one, and
+// returns the new report. Errors are shown instead of the report, as the
+// profile may be in the middle of being written.
+func renderWatch(config configuration, args arguments, writer io.Writer, previous *report.Report, clearScreen bool) (*report.Report, error) {
+	rep, _, err := generateReport(config, args)
+	if clearScreen {
+		fmt.Fprint(writer, "\x1b[H\x1b[2J")
+	}
+	fmt.Fprintf(writer, "%s %s\n", time.Now().Format("15:04:05"), args.coverprofile)
+	if err != nil {
+		fmt.Fprintln(writer, err)
+		return previous, err
+	}
+	shown, err := filterReport(rep, args)
+	if err != nil {
+		fmt.Fprintln(writer, err)
+		return previous, err
+	}
+	var highlight []report.TableOption
+	if previous != nil {
+		before, _ := filterReport(*previous, args)
+		highlight = append(highlight, report.WithChanges(report.Changes(before, shown)))
+	}
+	if err := printReport(shown, writer, config, args, highlight...); err != nil {
+		fmt.Fprintln(writer, err)
+		return previous, err
+	}
+	return &rep, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mcubik/goverreport/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Buffer that can be written by the watcher while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatch(t *testing.T) {
+	sample, err := os.ReadFile("sample_coverage.out")
+	require.NoError(t, err)
+	profile := filepath.Join(t.TempDir(), "coverage.out")
+	require.NoError(t, os.WriteFile(profile, sample, 0600))
+
+	args := arguments{coverprofile: profile, sortBy: "filename", order: "asc", watch: true}
+	config := configuration{Root: "github.com/mcubik/goverreport"}
+	buf := &syncBuffer{}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- runWatch(config, args, buf, stop) }()
+
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "Total") }, time.Second, 10*time.Millisecond)
+	assert.NotContains(t, buf.String(), "(stmt")
+
+	// Cover a block of main.go that wasn't covered
+	changed := strings.Replace(string(sample), "main.go:41.13,46.16 3 0", "main.go:41.13,46.16 3 1", 1)
+	require.NoError(t, os.WriteFile(profile, []byte(changed), 0600))
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "/main.go (stmt +") }, 2*time.Second, 10*time.Millisecond)
+
+	close(stop)
+	assert.NoError(t, <-done)
+}
+
+func TestWatchCommandWatchesModule(t *testing.T) {
+	sample, err := os.ReadFile("sample_coverage.out")
+	require.NoError(t, err)
+	module := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(module, "a"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(module, "b"), 0700))
+	profile := filepath.Join(module, "a", "coverage.out")
+	require.NoError(t, os.WriteFile(profile, sample, 0600))
+
+	args := arguments{coverprofile: profile, sortBy: "filename", order: "asc", watch: true,
+		watchCommand: "echo rebuilt", sourceDir: module}
+	buf := &syncBuffer{}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- runWatch(configuration{}, args, buf, stop) }()
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "Total") }, time.Second, 10*time.Millisecond)
+
+	// A package next to the one of the profile
+	require.NoError(t, os.WriteFile(filepath.Join(module, "b", "b.go"), []byte("package b\n"), 0600))
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "rebuilt") }, 2*time.Second, 10*time.Millisecond)
+
+	close(stop)
+	assert.NoError(t, <-done)
+}
+
+func TestRenderWatchInvalidProfile(t *testing.T) {
+	buf := bytes.Buffer{}
+	rep, err := renderWatch(configuration{}, arguments{coverprofile: "xxx.out"}, &buf, nil, true)
+	assert.Error(t, err)
+	assert.Nil(t, rep)
+	assert.Contains(t, buf.String(), "Invalid coverprofile")
+}
+
+func TestWatchSources(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"pkg/sub", ".git", "vendor/x", "testdata"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0750))
+	}
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+	require.NoError(t, watchSources(watcher, dir))
+	watched := watcher.WatchList()
+	assert.ElementsMatch(t, []string{dir, filepath.Join(dir, "pkg"), filepath.Join(dir, "pkg/sub")}, watched)
+}
+
+func TestRunWatchCommand(t *testing.T) {
+	buf := bytes.Buffer{}
+	runWatchCommand("go version", &buf)
+	assert.Contains(t, buf.String(), "Running go version")
+	assert.Contains(t, buf.String(), "go version go")
+
+	buf.Reset()
+	runWatchCommand("xxx-not-a-command", &buf)
+	assert.Contains(t, buf.String(), "not found")
+
+	buf.Reset()
+	runWatchCommand(`echo "quoted  argument"`, &buf)
+	assert.Contains(t, buf.String(), "quoted  argument\n")
+}
+
+func TestWatchBlankCommand(t *testing.T) {
+	args := arguments{coverprofile: "sample_coverage.out", watchCommand: "  "}
+	err := runWatch(configuration{}, args, &bytes.Buffer{}, nil)
+	assert.EqualError(t, err, "Invalid watch command '  ', it can't be blank")
+}
+
+func TestRenderWatchKeepsNames(t *testing.T) {
+	args := arguments{coverprofile: "sample_coverage.out", sortBy: "filename", order: "asc", format: "json"}
+	previous := &report.Report{}
+	buf := bytes.Buffer{}
+	_, err := renderWatch(configuration{}, args, &buf, previous, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "(new)", "Changes are only highlighted in tables")
+
+	buf.Reset()
+	args.format = "table"
+	_, err = renderWatch(configuration{}, args, &buf, previous, false)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "main.go (new)")
+}